@@ -14,19 +14,39 @@ var (
 )
 
 func main() {
-	schemaDir, _, err := collectCliArgs(os.Args[1:])
+	verify := len(os.Args) > 1 && os.Args[1] == "verify"
+	if verify {
+		// Drop the subcommand, so the generated code header matches `gospeak <args>`.
+		os.Args = append(os.Args[:1:1], os.Args[2:]...)
+	}
+
+	schemaDir, configFile, opts, _, err := collectCliArgs(os.Args[1:])
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n\n", err)
 		fmt.Fprintf(os.Stderr, usage)
 		os.Exit(1)
 	}
 
-	if schemaDir == "" {
-		fmt.Fprintf(os.Stderr, "<schema> is required: try gospeak --help\n")
-		os.Exit(1)
+	if schemaDir == "" && configFile == "" {
+		if _, err := os.Stat(gospeak.ConfigFile); err == nil {
+			configFile = gospeak.ConfigFile
+		}
 	}
 
-	targets, err := gospeak.Parse(schemaDir)
+	var targets []*gospeak.Target
+	if configFile != "" {
+		if schemaDir != "" {
+			fmt.Fprintf(os.Stderr, "<schema> can't be used with --config, the schemas are defined by %v\n", configFile)
+			os.Exit(1)
+		}
+		targets, err = parseConfig(configFile)
+	} else {
+		if schemaDir == "" {
+			fmt.Fprintf(os.Stderr, "<schema> is required: try gospeak --help\n")
+			os.Exit(1)
+		}
+		targets, err = gospeak.ParseWithOptions(schemaDir, opts)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to parse Go schema: %v\n", err)
 		os.Exit(1)
@@ -37,6 +57,7 @@ func main() {
 		os.Exit(1)
 	}
 
+	outdated := false
 	for _, target := range targets {
 		config := &gen.Config{
 			RefreshCache:    false,
@@ -50,12 +71,70 @@ func main() {
 			os.Exit(1)
 		}
 
+		if verify {
+			if err := verifyFile(target, generated.Code); err != nil {
+				fmt.Fprintf(os.Stderr, "%20v => %v ✗ %v\n", target.InterfaceName, target.OutFile, err)
+				outdated = true
+				continue
+			}
+			fmt.Printf("%20v => %v ✓ up to date\n", target.InterfaceName, target.OutFile)
+			continue
+		}
+
 		if err := os.WriteFile(target.OutFile, []byte(generated.Code), 0644); err != nil {
 			fmt.Fprintf(os.Stderr, "failed to write to %q file: %v\n", target.OutFile, err)
 			os.Exit(1)
 		}
 		fmt.Printf("%20v => %v ✓\n", target.InterfaceName, target.OutFile)
 	}
+
+	if outdated {
+		fmt.Fprintf(os.Stderr, "generated files are out of date, run gospeak to regenerate them\n")
+		os.Exit(1)
+	}
+}
+
+// Parses the schemas of the project configuration file. The flags of the file are
+// applied first, so the CLI flags can override them.
+func parseConfig(configFile string) ([]*gospeak.Target, error) {
+	config, err := gospeak.LoadConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, nestedConfig, _, _, err := collectCliArgs(config.Flags)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", configFile, err)
+	}
+	if schema != "" || nestedConfig != "" {
+		return nil, fmt.Errorf("%v: flags can't define <schema> or --config", configFile)
+	}
+
+	_, _, opts, _, err := collectCliArgs(append(config.Flags[:len(config.Flags):len(config.Flags)], os.Args[1:]...))
+	if err != nil {
+		return nil, err
+	}
+
+	return gospeak.ParseConfig(config, opts)
+}
+
+// Compares the committed target file with freshly generated code.
+func verifyFile(target *gospeak.Target, code string) error {
+	existing, err := os.ReadFile(target.OutFile)
+	if err != nil {
+		return fmt.Errorf("failed to read: %w", err)
+	}
+
+	if string(existing) == code {
+		return nil
+	}
+
+	// Most generators embed the schema hash, which tells us the Go sources have changed.
+	if schemaHash, err := target.Schema.SchemaHash(); err == nil && strings.Contains(code, schemaHash) && !strings.Contains(string(existing), schemaHash) {
+		return fmt.Errorf("schema hash mismatch: Go sources have changed")
+	}
+
+	return fmt.Errorf("generated code differs")
 }
 
 type Target struct {
@@ -64,13 +143,14 @@ type Target struct {
 	Opts map[string]interface{}
 }
 
-// gospeak <schema.go> <target> [-targetOpts...] -out=<file> ... [<targetN> [-targetOpts] -out=<file>...]
-func collectCliArgs(args []string) (schema string, targets []*Target, err error) {
+// gospeak [flags] <schema.go> <target> [-targetOpts...] -out=<file> ... [<targetN> [-targetOpts] -out=<file>...]
+func collectCliArgs(args []string) (schema string, configFile string, opts gospeak.Options, targets []*Target, err error) {
 	for i, arg := range args {
 		// CLI flags or target options
 		if strings.HasPrefix(arg, "-") {
 			// CLI flags
-			switch strings.TrimLeft(arg, "-") {
+			name, value, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			switch name {
 			case "h", "help":
 				fmt.Fprintf(os.Stdout, usage)
 				os.Exit(0)
@@ -79,8 +159,78 @@ func collectCliArgs(args []string) (schema string, targets []*Target, err error)
 				fmt.Println("gospeak", VERSION)
 				os.Exit(0)
 
+			case "config":
+				configFile = value
+
+			case "skip-unsupported-fields":
+				opts.SkipUnsupportedFields = true
+
+			case "best-effort":
+				opts.BestEffort = true
+
+			case "provenance":
+				opts.Provenance = true
+
+			case "const-enums":
+				opts.ConstEnums = true
+
+			case "allow-no-context":
+				opts.AllowNoContext = true
+
+			case "strict":
+				opts.Strict = true
+
+			case "keep-unused-types":
+				opts.KeepUnusedTypes = true
+
+			case "duration":
+				opts.Duration = value
+
+			case "byte-arrays":
+				opts.ByteArrays = value
+
+			case "url-strings":
+				opts.URLStrings = true
+
+			case "field-names":
+				opts.FieldNames = value
+
+			case "tags":
+				opts.BuildTags = append(opts.BuildTags, strings.Split(value, ",")...)
+
+			case "goos":
+				opts.GOOS = value
+
+			case "goarch":
+				opts.GOARCH = value
+
+			case "no-cgo":
+				opts.NoCgo = true
+
+			case "gowork":
+				opts.GoWork = value
+
+			case "internal-types":
+				opts.InternalTypes = value
+
+			case "lint":
+				opts.Lint = append(opts.Lint, strings.Split(value, ",")...)
+
+			case "map-type":
+				goType, webrpcType, ok := strings.Cut(value, "=")
+				if !ok {
+					return "", "", opts, nil, fmt.Errorf("invalid option %q, expected --map-type=<import/path.Type>=<webrpc type>", arg)
+				}
+				if opts.TypeMappings == nil {
+					opts.TypeMappings = map[string]string{}
+				}
+				opts.TypeMappings[goType] = webrpcType
+
+			case "nullable-type":
+				opts.NullableTypes = append(opts.NullableTypes, value)
+
 			default:
-				return "", nil, fmt.Errorf("unknown option %q", arg)
+				return "", "", opts, nil, fmt.Errorf("unknown option %q", arg)
 			}
 		} else {
 			if schema == "" {
@@ -131,16 +281,67 @@ func collectTargets(args []string) (targets []*Target, err error) {
 }
 
 const usage = `
-Usage: gospeak <schema.go>
+Usage: gospeak [flags] <schema.go>
+       gospeak verify [flags] <schema.go>
+       gospeak [verify] [flags] --config=gospeak.yaml
   -h, --help
         print this help
   -v, --version
         print gospeak version and exit
+  --config=<path/to/gospeak.yaml>
+        generate schemas and targets defined by the project configuration file,
+        instead of //go:webrpc comments (default ./gospeak.yaml if no <schema> given)
+  --skip-unsupported-fields
+        omit func, chan and unsafe.Pointer struct fields with a warning
+  --best-effort
+        generate interfaces that parse successfully, even if the package has errors
+  --provenance
+        record Go source positions of types and methods in the schema metadata
+  --const-enums
+        treat named integer and string types with a block of typed constants as enums
+  --allow-no-context
+        accept methods without context.Context argument, ie. Version() (string, error)
+  --strict
+        fail on types that would be silently sent as any, ie. fmt.Stringer or json.Marshaler
+  --keep-unused-types
+        keep types not referenced by any method, ie. unused enums (removed by default)
+  --duration=<ns|us|ms|s|string>
+        wire format of time.Duration values (default ns)
+  --byte-arrays=<hex|base64>
+        send fixed-size byte arrays, ie. [32]byte, as strings instead of lists of numbers
+  --url-strings
+        send url.URL as string instead of object, the generated code must convert it
+  --field-names=<camelCase|snake_case>
+        JSON names of struct fields without json tag (default Go field names)
+  --tags=<tag,...>
+        build tags used to load the packages, ie. --tags=integration
+  --goos=<os>, --goarch=<arch>
+        target platform used to load the packages (default current platform)
+  --no-cgo
+        load the packages with CGO_ENABLED=0, skipping files with import "C"
+  --gowork=<path/to/go.work|off>
+        Go workspace used to load the packages (default go.work in parent directories)
+  --internal-types=<error|copy>
+        report struct fields of internal package types, or generate copies of the types
+  --lint=<rule,...>
+        fail if the schema violates the lint rules (can be repeated):
+          no-any            reject any types, incl. elements of lists and maps
+          no-map-results    reject methods returning map<...> values
+          require-comments  require descriptions of services, methods, structs and enums
+  --map-type=<import/path.Type>=<webrpc type>
+        map Go type to webrpc core type, ie. --map-type=github.com/acme/money.Money=string
+        (can be repeated)
+  --nullable-type=<import/path.Type>
+        unwrap generic wrapper type, ie. --nullable-type=github.com/acme/opt.Option,
+        to optional field of the type argument, same as nullable.Nullable[T] (can be repeated)
 
 Finds all Go interfaces annotated with the special //go:webrpc target command comment.
 Creates Webrpc schema from the Go interface.
 Executes webrpc code generation for the given targets.
 
+The verify command generates the code in memory and exits with non-zero status
+if any of the target files is out of date.
+
 Example:
 
 package api
@@ -0,0 +1,160 @@
+package gospeak
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+	"gopkg.in/yaml.v3"
+)
+
+// Default name of the project configuration file.
+const ConfigFile = "gospeak.yaml"
+
+// Config is the repo-level project configuration, an alternative to the per-interface
+// //go:webrpc comments, so large projects can manage the generation centrally, ie.:
+//
+//	flags: [--const-enums, --duration=ms]
+//	type-mappings:
+//	  github.com/acme/money.Money: string
+//	schemas:
+//	  - package: ./api
+//	    interface: PetStore
+//	    targets:
+//	      - golang -server -pkg=api -out=./api/server.gen.go
+//	      - typescript -client -out=./web/{service}.gen.ts
+//
+// The targets use the same syntax as the //go:webrpc comments. Package and -out
+// paths are relative to the configuration file.
+type Config struct {
+	// CLI flags applied to all schemas, ie. --const-enums.
+	Flags []string `yaml:"flags"`
+
+	// Custom mappings of Go types to webrpc core types, same as Options.TypeMappings.
+	TypeMappings map[string]string `yaml:"type-mappings"`
+
+	Schemas []*ConfigSchema `yaml:"schemas"`
+
+	// Directory of the configuration file.
+	dir string
+}
+
+// Go interface of the schema package and its generated targets.
+type ConfigSchema struct {
+	Package   string   `yaml:"package"`
+	Interface string   `yaml:"interface"`
+	Targets   []string `yaml:"targets"`
+}
+
+// LoadConfig reads and validates the project configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	config := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true) // Report typos, ie. "target:" instead of "targets:".
+	if err := dec.Decode(config); err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %w", path, err)
+	}
+
+	if config.dir, err = filepath.Abs(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("failed to get directory of %q: %w", path, err)
+	}
+
+	if len(config.Schemas) == 0 {
+		return nil, fmt.Errorf("%v: no schemas defined", path)
+	}
+
+	seen := map[string]bool{}
+	for i, s := range config.Schemas {
+		if s == nil || s.Package == "" {
+			return nil, fmt.Errorf("%v: schemas[%v]: package is required", path, i)
+		}
+		if !token.IsIdentifier(s.Interface) {
+			return nil, fmt.Errorf("%v: schemas[%v]: invalid interface name %q", path, i, s.Interface)
+		}
+		if len(s.Targets) == 0 {
+			return nil, fmt.Errorf("%v: schemas[%v]: %v has no targets", path, i, s.Interface)
+		}
+
+		key := config.path(s.Package) + "." + s.Interface
+		if seen[key] {
+			return nil, fmt.Errorf("%v: schemas[%v]: %v of %v is defined twice, list all its targets in one entry", path, i, s.Interface, s.Package)
+		}
+		seen[key] = true
+	}
+
+	return config, nil
+}
+
+// ParseConfig parses the interfaces of the project configuration into the targets
+// defined by the configuration. The //go:webrpc comments of the packages are ignored.
+func ParseConfig(config *Config, opts Options) ([]*Target, error) {
+	// The options override the type mappings of the configuration file.
+	if len(config.TypeMappings) > 0 {
+		typeMappings := map[string]string{}
+		for goType, webrpcType := range config.TypeMappings {
+			typeMappings[goType] = webrpcType
+		}
+		for goType, webrpcType := range opts.TypeMappings {
+			typeMappings[goType] = webrpcType
+		}
+		opts.TypeMappings = typeMappings
+	}
+
+	if err := validateOptions(opts); err != nil {
+		return nil, err
+	}
+
+	pkgs := map[string]*packages.Package{}
+	var parsedTargets []*Target
+	for _, s := range config.Schemas {
+		// Load the packages shared by multiple schemas only once.
+		pkg, ok := pkgs[config.path(s.Package)]
+		if !ok {
+			var err error
+			pkg, err = loadPackage(config.path(s.Package), opts)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", s.Interface, err)
+			}
+			pkgs[config.path(s.Package)] = pkg
+		}
+
+		var targets []*Target
+		for _, cmd := range s.Targets {
+			target, err := parseWebrpcCommand(cmd)
+			if err != nil {
+				return nil, fmt.Errorf("%v: failed to parse target %q: %w", s.Interface, cmd, err)
+			}
+			target.InterfaceName = s.Interface
+			target.OutFile, err = expandOutFile(target, pkg.Name)
+			if err != nil {
+				return nil, fmt.Errorf("%v: failed to parse target %q: %w", s.Interface, cmd, err)
+			}
+			target.OutFile = config.path(target.OutFile)
+			targets = append(targets, target)
+		}
+
+		schemaTargets, err := parseTargets(pkg, targets, opts)
+		if err != nil {
+			return nil, err
+		}
+		parsedTargets = append(parsedTargets, schemaTargets...)
+	}
+
+	return parsedTargets, nil
+}
+
+// Resolves the path relative to the configuration file.
+func (c *Config) path(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.dir, filepath.FromSlash(path))
+}
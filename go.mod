@@ -7,6 +7,7 @@ require (
 	github.com/google/go-cmp v0.6.0
 	github.com/webrpc/webrpc v0.21.0
 	golang.org/x/tools v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
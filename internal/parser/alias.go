@@ -0,0 +1,14 @@
+//go:build !go1.22
+
+package parser
+
+import "go/types"
+
+// Type aliases are always resolved by go/types prior to Go 1.22.
+func unalias(typ types.Type) types.Type {
+	return typ
+}
+
+func wellKnownAlias(typ types.Type) (*types.TypeName, bool) {
+	return nil, false
+}
@@ -0,0 +1,25 @@
+//go:build go1.22
+
+package parser
+
+import "go/types"
+
+// Resolves type aliases. Since Go 1.23 (or Go 1.22 with GODEBUG=gotypesalias=1),
+// go/types represents aliases as *types.Alias instead of the aliased type.
+func unalias(typ types.Type) types.Type {
+	return types.Unalias(typ)
+}
+
+// Returns the alias kept in the Go type names, since it's visible from any package
+// and Go version, ie. any or json.RawMessage (alias of jsontext.Value in encoding/json v2).
+func wellKnownAlias(typ types.Type) (*types.TypeName, bool) {
+	alias, ok := typ.(*types.Alias)
+	if !ok {
+		return nil, false
+	}
+	obj := alias.Obj()
+	if obj.Pkg() == nil {
+		return obj, obj.Name() == "any"
+	}
+	return obj, obj.Pkg().Path() == "encoding/json" && obj.Name() == "RawMessage"
+}
@@ -1,12 +1,24 @@
 package parser
 
 import (
+	"fmt"
 	"go/types"
 
 	"github.com/webrpc/webrpc/schema"
 )
 
 func (p *Parser) ParseAny(typeName string, iface *types.Interface) (*schema.VarType, error) {
+	// Explicit any/interface{} is fine, but values of interfaces with methods,
+	// ie. fmt.Stringer, lose their type in JSON.
+	if !iface.Empty() {
+		if typeName == "" {
+			typeName = iface.String()
+		}
+		if err := p.strictAny("interface %v", typeName); err != nil {
+			return nil, err
+		}
+	}
+
 	varType := &schema.VarType{
 		Expr: "any",
 		Type: schema.T_Any,
@@ -14,3 +26,11 @@ func (p *Parser) ParseAny(typeName string, iface *types.Interface) (*schema.VarT
 
 	return varType, nil
 }
+
+// Returns error in the strict mode, which rejects types silently downgraded to any.
+func (p *Parser) strictAny(format string, args ...interface{}) error {
+	if !p.Strict {
+		return nil
+	}
+	return p.refErrorf("%v would be sent as any, use a concrete type or disable --strict", fmt.Sprintf(format, args...))
+}
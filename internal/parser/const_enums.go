@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"fmt"
+	"go/constant"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/webrpc/webrpc/schema"
+)
+
+// Collects enums defined as a named type with a block of typed constants, ie.:
+//
+//	// Status of the approval process.
+//	type Status int
+//
+//	const (
+//		StatusApproved Status = iota
+//		StatusPending
+//		StatusClosed
+//	)
+//
+// The values are named after the exported constants without the type name prefix,
+// ie. Approved. String enums are named after their values, ie.:
+//
+//	type Kind string
+//
+//	const (
+//		KindCat Kind = "cat"
+//		KindDog Kind = "dog"
+//	)
+//
+// Values documented with a "Deprecated:" paragraph get {"deprecated": "true"} meta.
+//
+// Types defining their own String(), MarshalText() or MarshalJSON() methods are
+// left alone, since the generated code defines these methods for enums.
+func (p *Parser) collectConstEnums() error {
+	for _, c := range exportedConsts(p.Pkg.Types) {
+		named, ok := c.Type().(*types.Named)
+		if !ok || named.Obj().Pkg() != p.Pkg.Types || !isConstEnumType(named) {
+			continue
+		}
+
+		key := named.String()
+		if _, ok := p.ParsedEnumTypes[key]; ok {
+			continue // type Status enum.Int, or already collected
+		}
+
+		enumType, err := p.parseConstEnum(named, named.Obj().Name())
+		if err != nil {
+			return err
+		}
+		p.addEnum(key, enumType, named.Obj().Pos())
+	}
+
+	return nil
+}
+
+// Parses enum of the given named type from the typed constants declared in its package.
+func (p *Parser) parseConstEnum(named *types.Named, enumName string) (*schema.Type, error) {
+	basic := named.Underlying().(*types.Basic)
+	enumElemType, ok := schema.CoreTypeFromString[basic.Name()]
+	if !ok {
+		return nil, fmt.Errorf("unknown enum type %v", basic.Name())
+	}
+
+	enumType := &schema.Type{
+		Kind: schema.TypeKind_Enum,
+		Name: enumName,
+		Type: &schema.VarType{
+			Expr: enumElemType.String(),
+			Type: enumElemType,
+		},
+		Fields:   []*schema.TypeField{},
+		Comments: p.getDocComments(named.Obj()),
+	}
+
+	if doc := p.getDocCommentGroup(named.Obj()); doc != nil {
+		for _, comment := range doc.List {
+			if _, err := parseEnumDirective(enumType, comment.Text); err != nil {
+				return nil, fmt.Errorf("%v: %w", p.Pkg.Fset.Position(named.Obj().Pos()), err)
+			}
+		}
+	}
+
+	for _, c := range exportedConsts(named.Obj().Pkg()) {
+		if !types.Identical(c.Type(), named) {
+			continue
+		}
+
+		name := strings.TrimPrefix(c.Name(), named.Obj().Name()) // StatusApproved => Approved
+		if name == "" {
+			name = c.Name()
+		}
+		value := c.Val().ExactString()
+
+		// String enum values are sent over the wire as they are.
+		if c.Val().Kind() == constant.String {
+			name = constant.StringVal(c.Val())
+			value = name
+			if !schema.IsValidArgName(name) {
+				return nil, fmt.Errorf("%v: %v: string enum value %q must be a valid identifier", p.Pkg.Fset.Position(c.Pos()), c.Name(), name)
+			}
+		}
+
+		field := &schema.TypeField{
+			Name:     name,
+			Comments: p.getDocComments(c),
+			TypeExtra: schema.TypeExtra{
+				Value: value,
+			},
+		}
+
+		if isDeprecated(field.Comments) {
+			field.Meta = append(field.Meta, schema.TypeFieldMeta{"deprecated": "true"})
+		}
+
+		enumType.Fields = append(enumType.Fields, field)
+	}
+
+	return enumType, nil
+}
+
+// Returns exported constants of the given package in declaration order.
+func exportedConsts(pkg *types.Package) []*types.Const {
+	scope := pkg.Scope()
+
+	// Scope names are sorted, we want the values in declaration order.
+	var consts []*types.Const
+	for _, name := range scope.Names() {
+		if c, ok := scope.Lookup(name).(*types.Const); ok && c.Exported() {
+			consts = append(consts, c)
+		}
+	}
+	sort.Slice(consts, func(i, j int) bool {
+		return consts[i].Pos() < consts[j].Pos()
+	})
+
+	return consts
+}
+
+// Reports whether the named type can be an enum of typed constants.
+func isConstEnumType(named *types.Named) bool {
+	basic, ok := named.Underlying().(*types.Basic)
+	if !ok || basic.Info()&(types.IsInteger|types.IsString) == 0 {
+		return false
+	}
+
+	for _, method := range []string{"String", "MarshalText", "MarshalJSON"} {
+		if obj, _, _ := types.LookupFieldOrMethod(named, true, named.Obj().Pkg(), method); obj != nil {
+			return false
+		}
+	}
+
+	return true
+}
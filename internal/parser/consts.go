@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/webrpc/webrpc/schema"
+)
+
+// CollectConsts collects const blocks of the schema package marked by the //gospeak:const
+// directive, so the generated clients and docs share the same values as the server, ie.:
+//
+//	// Limits of the list methods.
+//	//
+//	//gospeak:const Limits
+//	const (
+//		MaxPageSize     = 100
+//		DefaultPageSize = 20
+//	)
+//
+// Each block becomes a struct type with {"constants": "true"} meta, which is kept in
+// the schema even if no method refers to it. Its fields are the exported constants,
+// with the values recorded in {"const": "100"} meta as JSON literals.
+func (p *Parser) CollectConsts() error {
+	for _, file := range p.Pkg.Syntax {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.CONST {
+				continue
+			}
+			name, ok := directiveArg(genDecl.Doc, "//gospeak:const")
+			if !ok {
+				continue
+			}
+
+			constsType, err := p.parseConsts(genDecl, name)
+			if err != nil {
+				return err
+			}
+			p.setTypeSource(constsType, genDecl.Pos())
+			p.Schema.Types = append(p.Schema.Types, constsType)
+		}
+	}
+
+	return nil
+}
+
+// Parses the const block into a struct type of the given name.
+func (p *Parser) parseConsts(genDecl *ast.GenDecl, name string) (*schema.Type, error) {
+	if !token.IsIdentifier(name) {
+		return nil, fmt.Errorf("%v: invalid //gospeak:const directive %q, expected type name", p.Pkg.Fset.Position(genDecl.Pos()), name)
+	}
+	if _, ok := p.Pkg.Types.Scope().Lookup(name).(*types.TypeName); ok || p.isTypeNameTaken(name) {
+		return nil, fmt.Errorf("%v: type name %v given by //gospeak:const directive is already taken", p.Pkg.Fset.Position(genDecl.Pos()), name)
+	}
+	p.TypeNames[strings.ToLower(name)] = struct{}{}
+
+	constsType := &schema.Type{
+		Kind: schema.TypeKind_Struct,
+		Name: name,
+		TypeExtra: schema.TypeExtra{
+			Meta: []schema.TypeFieldMeta{{"constants": "true"}},
+		},
+	}
+	if text := strings.TrimSpace(genDecl.Doc.Text()); text != "" { // Text() drops the directives.
+		constsType.Comments = strings.Split(text, "\n")
+	}
+
+	for _, spec := range genDecl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		// The block's doc comment describes the type, not its only constant.
+		var comments []string
+		if text := strings.TrimSpace(valueSpec.Doc.Text()); text != "" {
+			comments = strings.Split(text, "\n")
+		}
+		for _, ident := range valueSpec.Names {
+			c, ok := p.Pkg.TypesInfo.Defs[ident].(*types.Const)
+			if !ok || !c.Exported() {
+				continue
+			}
+
+			field, err := p.parseConst(c, comments)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v: %w", p.Pkg.Fset.Position(c.Pos()), c.Name(), err)
+			}
+			constsType.Fields = append(constsType.Fields, field)
+		}
+	}
+
+	return constsType, nil
+}
+
+// Parses the constant into a struct field, ie. MaxPageSize int with {"const": "100"} meta.
+// Untyped constants get their default type, ie. int for `MaxPageSize = 100`.
+func (p *Parser) parseConst(c *types.Const, comments []string) (*schema.TypeField, error) {
+	basic, ok := unalias(types.Default(c.Type())).(*types.Basic)
+	if !ok {
+		return nil, fmt.Errorf("unsupported constant type %v, expected bool, number or string", c.Type())
+	}
+	varType, err := p.ParseBasic(basic)
+	if err != nil {
+		return nil, err
+	}
+
+	var value string
+	switch c.Val().Kind() {
+	case constant.Bool, constant.Int:
+		value = c.Val().ExactString()
+	case constant.Float:
+		f, _ := constant.Float64Val(c.Val())
+		value = strconv.FormatFloat(f, 'g', -1, 64)
+	case constant.String:
+		b, _ := json.Marshal(constant.StringVal(c.Val()))
+		value = string(b)
+	default:
+		return nil, fmt.Errorf("unsupported constant value %v", c.Val())
+	}
+
+	return &schema.TypeField{
+		Name:     c.Name(),
+		Type:     varType,
+		Comments: comments,
+		TypeExtra: schema.TypeExtra{
+			Meta: []schema.TypeFieldMeta{{"const": value}},
+		},
+	}, nil
+}
+
+// Reports whether the type holds constants collected by CollectConsts().
+func isConstsType(typ *schema.Type) bool {
+	for _, meta := range typ.Meta {
+		if meta["constants"] == "true" {
+			return true
+		}
+	}
+	return false
+}
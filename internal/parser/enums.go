@@ -4,20 +4,65 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"strconv"
 	"strings"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/webrpc/webrpc/schema"
 )
 
-// CollectEnums collects ENUM definitions, ie.:
+// CollectEnums collects ENUM definitions of the schema package.
+func (p *Parser) CollectEnums() error {
+	if err := p.collectGospeakEnums(); err != nil {
+		return err
+	}
+
+	if p.ConstEnums {
+		if err := p.collectConstEnums(); err != nil {
+			return err
+		}
+	}
+
+	// Enums renamed by the //gospeak:name directive. Nothing refers to them yet.
+	for _, enumType := range p.Schema.Types {
+		obj, ok := p.Pkg.Types.Scope().Lookup(enumType.Name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		if name, _ := p.getDirective(obj, "//gospeak:name"); name == obj.Name() {
+			continue // Already named so.
+		}
+		name, ok, err := p.typeNameDirective(obj)
+		if err != nil {
+			return err
+		}
+		if ok {
+			enumType.Name = name
+			enumType.Meta = append(enumType.Meta, schema.TypeFieldMeta{"go.type.name": obj.Name()})
+		}
+	}
+
+	return nil
+}
+
+// Collects gospeak ENUM definitions, ie.:
 //
+//	// Status of the approval process.
+//	//
 //	// approved = 0
 //	// pending  = 1
 //	// closed   = 2
-//	// new      = 3
+//	// new      = "NEW"
 //	type Status gospeak.Enum[int]
-func (p *Parser) CollectEnums() error {
+//
+// The description is optional and must be separated from the values by an empty line.
+// Quoted values are sent over the wire instead of the names, see {"json": "NEW"} meta.
+// Values without a number continue from the previous one, ie. new = 3. The numbers
+// and the wire strings must be unique.
+// Values marked as `// closed (deprecated)` get {"deprecated": "true"} meta.
+// The `//go:webrpc json=int` directive sends the integer values instead of the names.
+func (p *Parser) collectGospeakEnums() error {
 	debug := spew.NewDefaultConfig()
 	debug.DisableMethods = true
 	debug.DisablePointerAddresses = true
@@ -47,69 +92,240 @@ func (p *Parser) CollectEnums() error {
 			if typeDeclaration, ok := decl.(*ast.GenDecl); ok && typeDeclaration.Tok == token.TYPE {
 				for _, spec := range typeDeclaration.Specs {
 					if typeSpec, ok := spec.(*ast.TypeSpec); ok {
-						selExpr, ok := typeSpec.Type.(*ast.SelectorExpr)
-						if !ok {
-							continue
+						enumType, err := parseGospeakEnum(typeDeclaration, typeSpec, typeSpec.Name.Name)
+						if err != nil {
+							return fmt.Errorf("%v: %w", p.Pkg.Fset.Position(typeSpec.Pos()), err)
 						}
-						ident, ok := selExpr.X.(*ast.Ident)
-						if !ok {
+						if enumType == nil {
 							continue
 						}
 
-						// type Status enum.Int64
-						enumName := typeSpec.Name.Name   // Status
-						pkgName := ident.Name            // enum
-						enumTypeName := selExpr.Sel.Name // Int64
-						if pkgName != "enum" || enumName == "" || enumTypeName == "" {
-							continue
-						}
+						p.addEnum(fmt.Sprintf("%v.%v", p.Pkg.PkgPath, typeSpec.Name.Name), enumType, typeSpec.Pos())
+					}
+				}
+			}
+		}
+	}
 
-						enumElemType, ok := schema.CoreTypeFromString[strings.ToLower(enumTypeName)]
-						if !ok {
-							return fmt.Errorf("unknown enum type %v", enumTypeName)
-						}
+	return nil
+}
 
-						enumType := &schema.Type{
-							Kind: schema.TypeKind_Enum,
-							Name: enumName,
-							Type: &schema.VarType{
-								Expr: enumElemType.String(),
-								Type: enumElemType,
-							},
-							Fields: []*schema.TypeField{}, // webrpc TODO: should be Enums
-						}
+// Returns enum of the given named type, or nil if the type is not an enum.
+//
+// Enums of the schema package are collected upfront. Enums declared in other
+// packages (ie. internal/model) are parsed on first use from their own source.
+func (p *Parser) lookupEnum(named *types.Named) (*schema.Type, error) {
+	key := named.String() // pkg/path.Status
+	if enumType, ok := p.ParsedEnumTypes[key]; ok {
+		return enumType, nil
+	}
 
-						doc := typeDeclaration.Doc
-						if doc != nil {
-							// name       value
-							// ----------------
-							// approved = 0
-							// pending  = 1
-							// closed   = 2
-							// new      = 3
-							for i, comment := range doc.List {
-								commentValue, _ := strings.CutPrefix(comment.Text, "//")
-								name, value, found := strings.Cut(commentValue, "=") // approved = 0
-								if !found {                                          // approved
-									name = commentValue
-									value = fmt.Sprintf("%v", i)
-								}
-								enumType.Fields = append(enumType.Fields, &schema.TypeField{
-									Name: strings.TrimSpace(name),
-									TypeExtra: schema.TypeExtra{
-										Value: strings.TrimSpace(value),
-									},
-								})
-							}
-						}
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg() == p.Pkg.Types {
+		return nil, nil
+	}
+	if _, ok := named.Underlying().(*types.Basic); !ok {
+		return nil, nil
+	}
 
-						p.Schema.Types = append(p.Schema.Types, enumType)
-						p.ParsedEnumTypes[fmt.Sprintf("%v.%v", p.Pkg.PkgPath, enumName)] = enumType
-					}
+	var enumType *schema.Type
+	if typeDeclaration, typeSpec := p.getTypeSpec(obj); typeSpec != nil {
+		var err error
+		enumType, err = parseGospeakEnum(typeDeclaration, typeSpec, obj.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", p.Pkg.Fset.Position(obj.Pos()), err)
+		}
+	}
+	if enumType == nil && p.ConstEnums && isConstEnumType(named) {
+		var err error
+		enumType, err = p.parseConstEnum(named, obj.Name())
+		if err != nil {
+			return nil, err
+		}
+		if len(enumType.Fields) == 0 {
+			enumType = nil // Named type without any constants.
+		}
+	}
+	if enumType == nil {
+		return nil, nil
+	}
+
+	name, meta, err := p.namedWebrpcTypeName(named) // model.Status => modelStatus
+	if err != nil {
+		return nil, err
+	}
+	enumType.Name = name
+	enumType.Meta = append(enumType.Meta, meta...)
+	p.addEnum(key, enumType, obj.Pos())
+
+	return enumType, nil
+}
+
+// Adds the enum to the schema.
+func (p *Parser) addEnum(key string, enumType *schema.Type, pos token.Pos) {
+	p.setTypeSource(enumType, pos)
+
+	p.Schema.Types = append(p.Schema.Types, enumType)
+	p.ParsedEnumTypes[key] = enumType
+}
+
+// Parses enum directive, ie. `//go:webrpc json=int`, into the enum type meta.
+// Reports whether the comment is a directive.
+//
+// Enums are sent over the wire as value names by default. The json=int directive
+// tells the generators to send the underlying integer values instead.
+func parseEnumDirective(enumType *schema.Type, comment string) (bool, error) {
+	directive, ok := strings.CutPrefix(comment, "//go:webrpc ")
+	if !ok {
+		return false, nil
+	}
+
+	for _, arg := range strings.Fields(directive) {
+		name, value, _ := strings.Cut(arg, "=")
+		switch name {
+		case "json":
+			if value != "int" && value != "string" {
+				return true, fmt.Errorf("%v enum: invalid %q directive, expected json=int or json=string", enumType.Name, arg)
+			}
+			if value == "int" && enumType.Type.Type == schema.T_String {
+				return true, fmt.Errorf("%v enum: json=int directive requires an integer enum type, got %v", enumType.Name, enumType.Type.Expr)
+			}
+			enumType.Meta = append(enumType.Meta, schema.TypeFieldMeta{"json": value})
+
+		default:
+			return true, fmt.Errorf("%v enum: unknown %q directive", enumType.Name, arg)
+		}
+	}
+
+	return true, nil
+}
+
+// Parses gospeak enum declaration, ie. `type Status enum.Int`, along with its values
+// defined in the doc comment. Returns nil, if the type spec is not an enum.
+func parseGospeakEnum(typeDeclaration *ast.GenDecl, typeSpec *ast.TypeSpec, enumName string) (*schema.Type, error) {
+	selExpr, ok := typeSpec.Type.(*ast.SelectorExpr)
+	if !ok {
+		return nil, nil
+	}
+	ident, ok := selExpr.X.(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+
+	// type Status enum.Int64
+	pkgName := ident.Name            // enum
+	enumTypeName := selExpr.Sel.Name // Int64
+	if pkgName != "enum" || enumName == "" || enumTypeName == "" {
+		return nil, nil
+	}
+
+	enumElemType, ok := schema.CoreTypeFromString[strings.ToLower(enumTypeName)]
+	if !ok {
+		return nil, fmt.Errorf("unknown enum type %v", enumTypeName)
+	}
+
+	enumType := &schema.Type{
+		Kind: schema.TypeKind_Enum,
+		Name: enumName,
+		Type: &schema.VarType{
+			Expr: enumElemType.String(),
+			Type: enumElemType,
+		},
+		Fields: []*schema.TypeField{}, // webrpc TODO: should be Enums
+	}
+
+	doc := typeDeclaration.Doc
+	if doc != nil {
+		var valueComments []*ast.Comment
+		for _, comment := range doc.List {
+			ok, err := parseEnumDirective(enumType, comment.Text)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				valueComments = append(valueComments, comment)
+			}
+		}
+
+		// gofmt separates the trailing directives by an empty line.
+		for len(valueComments) > 0 && strings.TrimSpace(strings.TrimPrefix(valueComments[len(valueComments)-1].Text, "//")) == "" {
+			valueComments = valueComments[:len(valueComments)-1]
+		}
+
+		// Optional description, separated from the values by an empty line.
+		for i := len(valueComments) - 1; i >= 0; i-- {
+			if strings.TrimSpace(strings.TrimPrefix(valueComments[i].Text, "//")) == "" {
+				for _, comment := range valueComments[:i] {
+					enumType.Comments = append(enumType.Comments, strings.TrimSpace(strings.TrimPrefix(comment.Text, "//")))
 				}
+				valueComments = valueComments[i+1:]
+				break
 			}
 		}
+
+		// name       value
+		// ----------------
+		// approved = 0
+		// pending  = 1
+		// closed   = 2
+		// new      = "NEW"
+		// closed   = 3 (deprecated)
+		//
+		// Values without an explicit number continue from the previous one, same as iota.
+		var next int64
+		names := map[string]string{}  // Wire strings and their value names.
+		values := map[string]string{} // Numeric values and their value names.
+		for _, comment := range valueComments {
+			commentValue, _ := strings.CutPrefix(comment.Text, "//")
+			commentValue, deprecated := cutDeprecated(commentValue)
+			name, value, found := strings.Cut(commentValue, "=") // approved = 0
+			if !found {                                          // approved
+				name = commentValue
+				value = fmt.Sprintf("%v", next)
+			}
+			field := &schema.TypeField{
+				Name: strings.TrimSpace(name),
+				TypeExtra: schema.TypeExtra{
+					Value: strings.TrimSpace(value),
+				},
+			}
+
+			// Explicit string value sent over the wire instead of the name.
+			wireValue := field.Name
+			if quoted, err := strconv.Unquote(field.Value); err == nil {
+				wireValue = quoted
+				field.Value = fmt.Sprintf("%v", next)
+				field.Meta = append(field.Meta, schema.TypeFieldMeta{"json": quoted})
+			}
+			if n, err := strconv.ParseInt(field.Value, 10, 64); err == nil {
+				next = n + 1
+			}
+
+			if other, ok := names[wireValue]; ok {
+				return nil, fmt.Errorf("%v enum: %v is sent as %q, same as %v", enumName, field.Name, wireValue, other)
+			}
+			names[wireValue] = field.Name
+			if other, ok := values[field.Value]; ok {
+				return nil, fmt.Errorf("%v enum: %v has value %v, same as %v", enumName, field.Name, field.Value, other)
+			}
+			values[field.Value] = field.Name
+
+			if deprecated {
+				field.Meta = append(field.Meta, schema.TypeFieldMeta{"deprecated": "true"})
+			}
+
+			enumType.Fields = append(enumType.Fields, field)
+		}
 	}
 
-	return nil
+	return enumType, nil
+}
+
+// Cuts the "(deprecated)" marker out of the enum value comment, ie. `closed (deprecated)`.
+func cutDeprecated(comment string) (string, bool) {
+	before, after, found := strings.Cut(comment, "(deprecated)")
+	if !found {
+		return comment, false
+	}
+	return before + after, true
 }
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Returns JSON name of the untagged struct field, converted per p.FieldNames,
+// ie. `UserID` => `userID` (camelCase) or `user_id` (snake_case).
+func (p *Parser) jsonFieldName(goFieldName string) string {
+	switch p.FieldNames {
+	case "camelCase":
+		return toCamelCase(goFieldName)
+	case "snake_case":
+		return toSnakeCase(goFieldName)
+	default:
+		return goFieldName
+	}
+}
+
+// Lowercases the first word of the Go field name, incl. initialisms,
+// ie. `ID` => `id`, `UserID` => `userID`, `HTTPServer` => `httpServer`.
+func toCamelCase(name string) string {
+	words := splitFieldName(name)
+	if len(words) == 0 {
+		return name
+	}
+	return strings.ToLower(words[0]) + strings.Join(words[1:], "")
+}
+
+// Lowercases and joins the words of the Go field name by underscores,
+// ie. `UserID` => `user_id`, `HTTPServer` => `http_server`.
+func toSnakeCase(name string) string {
+	words := splitFieldName(name)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, "_")
+}
+
+// Splits the Go field name into words on case changes and underscores,
+// ie. `HTTPServerV2` => ["HTTP", "Server", "V2"] or `UserIDs` => ["User", "IDs"].
+// Digits stick to the preceding word.
+func splitFieldName(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '_' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i == start || !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		prev := runes[i-1]
+		nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		// Plural initialism, ie. IDs, URLs or IDsByName. The trailing s stays with the initialism.
+		if nextIsLower && runes[i+1] == 's' && (i+2 == len(runes) || !unicode.IsLower(runes[i+2])) {
+			nextIsLower = false
+		}
+		// userID => user|ID, HTTPServer => HTTP|Server
+		if !unicode.IsUpper(prev) || nextIsLower {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,45 @@
+package parser
+
+import "testing"
+
+func TestFieldNames(t *testing.T) {
+	tt := []struct {
+		in        string
+		camelCase string
+		snakeCase string
+	}{
+		{in: "ID", camelCase: "id", snakeCase: "id"},
+		{in: "Name", camelCase: "name", snakeCase: "name"},
+		{in: "UserID", camelCase: "userID", snakeCase: "user_id"},
+		{in: "CreatedAt", camelCase: "createdAt", snakeCase: "created_at"},
+		{in: "HTTPServer", camelCase: "httpServer", snakeCase: "http_server"},
+		{in: "ServerHTTP", camelCase: "serverHTTP", snakeCase: "server_http"},
+		{in: "Address2", camelCase: "address2", snakeCase: "address2"},
+		{in: "OAuth2Token", camelCase: "oAuth2Token", snakeCase: "o_auth2_token"},
+		{in: "IDs", camelCase: "ids", snakeCase: "ids"},
+		{in: "UserIDs", camelCase: "userIDs", snakeCase: "user_ids"},
+		{in: "URLs", camelCase: "urls", snakeCase: "urls"},
+		{in: "IDsByName", camelCase: "idsByName", snakeCase: "ids_by_name"},
+		{in: "HTTPServers", camelCase: "httpServers", snakeCase: "http_servers"},
+		{in: "ASet", camelCase: "aSet", snakeCase: "a_set"},
+		{in: "Snake_Case", camelCase: "snakeCase", snakeCase: "snake_case"},
+		{in: "Ünicode", camelCase: "ünicode", snakeCase: "ünicode"},
+	}
+
+	for _, tc := range tt {
+		p := &Parser{FieldNames: "camelCase"}
+		if got := p.jsonFieldName(tc.in); got != tc.camelCase {
+			t.Errorf("camelCase(%q): expected %q, got %q", tc.in, tc.camelCase, got)
+		}
+
+		p = &Parser{FieldNames: "snake_case"}
+		if got := p.jsonFieldName(tc.in); got != tc.snakeCase {
+			t.Errorf("snake_case(%q): expected %q, got %q", tc.in, tc.snakeCase, got)
+		}
+
+		p = &Parser{}
+		if got := p.jsonFieldName(tc.in); got != tc.in {
+			t.Errorf("jsonFieldName(%q): expected %q, got %q", tc.in, tc.in, got)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/webrpc/webrpc/schema"
+)
+
+// Appends {"default": value} or {"example": value} meta to the struct field, given by
+// the struct tag, ie. `default:"10"`, or by the doc comment line, ie. `// example: "fluffy"`,
+// which is cut out of the field comments. The value is recorded as a JSON literal.
+func (p *Parser) appendFieldValue(field *schema.TypeField, structTags string, key string) error {
+	comments, value, ok := cutCommentValue(field.Comments, key)
+	field.Comments = comments
+
+	if tagValue, hasTag := reflect.StructTag(structTags).Lookup(key); hasTag {
+		value, ok = tagValue, true // Struct tag wins over the comment.
+	}
+	if !ok {
+		return nil
+	}
+
+	literal, err := jsonLiteral(value, field.Type)
+	if err != nil {
+		return p.refErrorf("invalid %v value of %v: %w", key, field.Name, err)
+	}
+	field.TypeExtra.Meta = append(field.TypeExtra.Meta, schema.TypeFieldMeta{key: literal})
+
+	return nil
+}
+
+// Cuts the `key: value` line out of the doc comments, ie. `default: 10`.
+func cutCommentValue(comments []string, key string) ([]string, string, bool) {
+	for i, comment := range comments {
+		value, ok := strings.CutPrefix(comment, key+":")
+		if !ok {
+			continue
+		}
+
+		rest := append(comments[:i:i], comments[i+1:]...)
+		if len(rest) == 0 {
+			rest = nil
+		}
+		return rest, strings.TrimSpace(value), true
+	}
+	return comments, "", false
+}
+
+// Returns the value as a JSON literal of the given type. Values of string types
+// (incl. enums and timestamps) may be given without quotes, ie. `fluffy`.
+func jsonLiteral(value string, varType *schema.VarType) (string, error) {
+	if varType != nil && (varType.Type == schema.T_String || varType.Type == schema.T_Timestamp) {
+		var s string
+		if !strings.HasPrefix(value, `"`) {
+			b, _ := json.Marshal(value)
+			return string(b), nil
+		}
+		if err := json.Unmarshal([]byte(value), &s); err != nil {
+			return "", fmt.Errorf("invalid string %v", value)
+		}
+		return value, nil
+	}
+
+	if !json.Valid([]byte(value)) {
+		return "", fmt.Errorf("%q is not a JSON value", value)
+	}
+	return value, nil
+}
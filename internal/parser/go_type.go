@@ -1,61 +1,173 @@
 package parser
 
 import (
+	"fmt"
+	"go/token"
 	"go/types"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"github.com/webrpc/webrpc/schema"
 )
 
 func (p *Parser) GoTypeName(typ types.Type) string {
-	name := typ.String() // []*github.com/golang-cz/gospeak/pkg.Typ
-
-	if typNamed, ok := typ.(*types.Named); ok {
-		// Versioned packages.
-		// github.com/gofrs/uuid/v5.UUID => // github.com/gofrs/uuid.UUID
-		if typNamed.Obj().Pkg() != nil {
-			if !strings.Contains(name, typNamed.Obj().Pkg().Name()+".") {
-				name = strings.ReplaceAll(name, typNamed.Obj().Type().String(), typNamed.Obj().Pkg().Name()+"."+typNamed.Obj().Name())
-			}
-		}
+	name := strings.ReplaceAll(p.goTypeExpr(typ), "*", "") // []pkg.Typ
+
+	if name == "invalid type" {
+		name = "invalidType"
 	}
 
-	name = strings.ReplaceAll(name, "*", "") // []github.com/golang-cz/gospeak/pkg.Typ
+	return name
+}
 
-	firstLetter := findFirstLetter(name)
-	prefix := name[:firstLetter] // []
-	name = name[firstLetter:]    // github.com/golang-cz/gospeak/pkg.Typ
+// Returns the Go type expression used by the generated code, ie. []*pkg.Typ, see {"go.field.type": "[]*pkg.Typ"} meta.
+func (p *Parser) goTypeExpr(typ types.Type) string {
+	typ = unaliasAll(typ) // Aliases might not be visible from the generated code.
 
-	name = strings.TrimPrefix(name, p.SchemaPkgName+".")       // Typ (ignore root Pkg)
-	name = strings.TrimPrefix(name, "command-line-arguments.") // Typ (ignore "command-line-arguments" Pkg autogenerated by Go tool chain)
-	name = filepath.Base(name)                                 // pkg.Typ
+	return types.TypeString(typ, p.qualifier) // []*pkg.Typ, map[string]pkg.Typ
+}
 
-	if name == "invalid type" {
-		name = "invalidType"
+// Qualifies types by package name, except for the schema package.
+// Versioned packages: github.com/gofrs/uuid/v5.UUID => uuid.UUID
+func (p *Parser) qualifier(pkg *types.Package) string {
+	switch pkg.Path() {
+	case p.SchemaPkgName, "command-line-arguments": // "command-line-arguments" pkg is autogenerated by Go tool chain
+		return ""
 	}
+	return pkg.Name()
+}
 
-	return prefix + name // []*pkg.Typ
+// Returns the Go type name of the generic type instance, which tells apart the pointer,
+// list and map type arguments, ie. Page[PetPtr] for Page[*Pet] and Page[PetList] for Page[[]Pet].
+func (p *Parser) instanceTypeName(named *types.Named) string {
+	var args []string
+	for i := 0; i < named.TypeArgs().Len(); i++ {
+		args = append(args, p.typeArgName(named.TypeArgs().At(i)))
+	}
+	name := named.Obj().Name()
+	if pkg := named.Obj().Pkg(); pkg != nil && p.qualifier(pkg) != "" {
+		name = p.qualifier(pkg) + "." + name
+	}
+	return name + "[" + strings.Join(args, ",") + "]"
+}
+
+func (p *Parser) typeArgName(typ types.Type) string {
+	switch v := unaliasAll(typ).(type) {
+	case *types.Pointer:
+		return p.typeArgName(v.Elem()) + "Ptr"
+	case *types.Slice:
+		return p.typeArgName(v.Elem()) + "List"
+	case *types.Array:
+		return p.typeArgName(v.Elem()) + "List"
+	case *types.Map:
+		return p.typeArgName(v.Key()) + p.typeArgName(v.Elem()) + "Map"
+	case *types.Named:
+		if v.TypeArgs().Len() > 0 {
+			return p.instanceTypeName(v)
+		}
+	}
+	return p.GoTypeName(typ)
 }
 
 func (p *Parser) GoTypeImport(typ types.Type) string {
-	name := typ.String() // []*github.com/golang-cz/gospeak/pkg.Typ
+	if obj, ok := wellKnownAlias(typ); ok {
+		if obj.Pkg() == nil {
+			return "" // any
+		}
+		return obj.Pkg().Path() // encoding/json
+	}
 
-	firstLetter := findFirstLetter(name)
-	name = name[firstLetter:] // github.com/golang-cz/gospeak/pkg.Typ
+	switch v := unalias(typ).(type) {
+	case *types.Pointer:
+		return p.GoTypeImport(v.Elem()) // *pkg.Typ
+	case *types.Slice:
+		return p.GoTypeImport(v.Elem()) // []pkg.Typ
+	case *types.Array:
+		return p.GoTypeImport(v.Elem()) // [N]pkg.Typ
+	case *types.Map:
+		// map[string]pkg.Typ
+		if valueImport := p.GoTypeImport(v.Elem()); valueImport != "" {
+			return valueImport
+		}
+		return p.GoTypeImport(v.Key()) // map[pkg.Typ]string
+	case *types.Named:
+		pkg := v.Obj().Pkg()
+		if pkg == nil {
+			return "" // error
+		}
 
-	lastDot := strings.LastIndex(name, ".")
-	if lastDot <= 0 {
-		return ""
+		switch pkg.Path() {
+		case p.SchemaPkgName, "command-line-arguments", "time":
+			return ""
+		}
+
+		return pkgImportPath(pkg) // github.com/golang-cz/gospeak/pkg
 	}
 
-	name = name[:lastDot] // github.com/golang-cz/gospeak/pkg
-	switch name {
-	case p.SchemaPkgName, "command-line-arguments", "time":
-		return ""
+	return ""
+}
+
+// Returns the path the package is imported by in Go source code. Packages of vendored
+// dependencies might be loaded as app/vendor/github.com/pkg, which can't be imported.
+func pkgImportPath(pkg *types.Package) string {
+	return vendorlessPath(pkg.Path())
+}
+
+// Strips the vendor directory prefix, ie. app/vendor/github.com/pkg => github.com/pkg.
+func vendorlessPath(importPath string) string {
+	if i := strings.LastIndex(importPath, "/vendor/"); i >= 0 {
+		return importPath[i+len("/vendor/"):]
 	}
+	return strings.TrimPrefix(importPath, "vendor/")
+}
 
-	return name
+// Handles struct fields of types declared in internal packages, ie. acme.com/app/internal/model,
+// which can't be imported by the generated code outside of acme.com/app. See p.InternalTypes.
+func (p *Parser) checkInternalImport(field *schema.TypeField, goFieldType string, goFieldImport string) error {
+	root, ok := internalRoot(goFieldImport)
+	if !ok {
+		return nil
+	}
+
+	switch p.InternalTypes {
+	case "error":
+		return p.refErrorf("type %v of internal package %v can't be imported by the generated code outside of %v, see --internal-types=copy", strings.TrimLeft(goFieldType, "*"), goFieldImport, root)
+
+	case "copy":
+		// Let the generators use their own copy of the type, ie. modelItem instead of model.Item.
+		var meta []schema.TypeFieldMeta
+		for _, m := range field.TypeExtra.Meta {
+			if _, ok := m["go.field.type"]; ok {
+				continue
+			}
+			if _, ok := m["go.type.import"]; ok {
+				continue
+			}
+			meta = append(meta, m)
+		}
+		field.TypeExtra.Meta = meta
+	}
+
+	return nil
+}
+
+// Returns the path the internal package can be imported from, ie. acme.com/app
+// for acme.com/app/internal/model, and reports whether the package is internal.
+func internalRoot(importPath string) (string, bool) {
+	if importPath == "internal" || strings.HasPrefix(importPath, "internal/") {
+		return "", true
+	}
+	if i := strings.LastIndex(importPath, "/internal/"); i >= 0 {
+		return importPath[:i], true
+	}
+	if strings.HasSuffix(importPath, "/internal") {
+		return strings.TrimSuffix(importPath, "/internal"), true
+	}
+	return "", false
 }
 
 func (p *Parser) GoTypeNameToWebrpc(typ string) string {
@@ -65,13 +177,287 @@ func (p *Parser) GoTypeNameToWebrpc(typ string) string {
 	return before + after
 }
 
-func findFirstLetter(s string) int {
-	for i, char := range s {
-		if unicode.IsLetter(char) {
-			return i
+// Returns valid webrpc type name for the given Go type name, which is not used by
+// any other type in the schema yet. Duplicates get a numeric suffix, ie. `AnonymousField2`.
+func (p *Parser) uniqueWebrpcTypeName(goTypeName string) string {
+	name := sanitizeTypeName(p.GoTypeNameToWebrpc(goTypeName))
+
+	unique := name
+	for i := 2; p.isTypeNameTaken(unique); i++ {
+		unique = fmt.Sprintf("%v%v", name, i)
+	}
+	p.TypeNames[strings.ToLower(unique)] = struct{}{}
+
+	return unique
+}
+
+// Returns unique webrpc type name for the given named type, ie. `configConfig` for
+// config.Config, along with the type meta. If the name is taken by a type of another
+// package of the same name, it's prefixed with the parent directories of the import path,
+// ie. `otherConfigConfig` for github.com/other/config.Config, and the import path is
+// recorded in {"go.type.import": "github.com/other/config"} meta. Types renamed by the
+// //gospeak:name directive get {"go.type.name": "Config"} meta instead.
+//
+// The names of the colliding types used by the service are decided upfront, so they
+// don't depend on the parsing order, see qualifyCollidingTypes().
+func (p *Parser) namedWebrpcTypeName(named *types.Named) (string, []schema.TypeFieldMeta, error) {
+	obj := named.Obj()
+	if name, ok, err := p.typeNameDirective(obj); err != nil || ok {
+		return name, []schema.TypeFieldMeta{{"go.type.name": obj.Name()}}, err
+	}
+
+	goTypeName := p.GoTypeName(named)
+	if named.TypeArgs().Len() > 0 {
+		goTypeName = p.instanceTypeName(named) // Page[PetPtr]
+	}
+	name := sanitizeTypeName(p.GoTypeNameToWebrpc(goTypeName))
+
+	pkg := obj.Pkg()
+	if pkg == nil || pkg == p.Pkg.Types {
+		return p.uniqueWebrpcTypeName(goTypeName), nil, nil
+	}
+
+	importPath := pkgImportPath(pkg)
+	meta := []schema.TypeFieldMeta{{"go.type.import": importPath}}
+	if qualified, ok := p.qualifiedTypeNames[obj]; ok && !p.isTypeNameTaken(qualified) {
+		p.TypeNames[strings.ToLower(qualified)] = struct{}{}
+		return qualified, meta, nil
+	}
+	if !p.isTypeNameTaken(name) {
+		return p.uniqueWebrpcTypeName(goTypeName), nil, nil
+	}
+
+	qualified := name
+	dirs := strings.Split(path.Dir(importPath), "/")
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i] == "." {
+			break
+		}
+		qualified = sanitizeTypeName(dirs[i] + "." + qualified)
+		if !p.isTypeNameTaken(qualified) {
+			p.TypeNames[strings.ToLower(qualified)] = struct{}{}
+			return qualified, meta, nil
+		}
+	}
+
+	return p.uniqueWebrpcTypeName(goTypeName), meta, nil
+}
+
+// Finds the named types of the same webrpc name used by the service methods, ie. config.Config
+// of github.com/acme/config and github.com/other/config, and qualifies all of them but one.
+// The type of the schema package keeps the name, otherwise the type of the lexically first
+// import path does, ie. `configConfig` and `otherConfigConfig`.
+func (p *Parser) qualifyCollidingTypes(iface *types.Interface) {
+	byName := map[string][]*types.TypeName{}
+	seen := map[*types.Named]bool{}
+
+	var walk func(typ types.Type)
+	walk = func(typ types.Type) {
+		switch v := unalias(typ).(type) {
+		case *types.Pointer:
+			walk(v.Elem())
+		case *types.Slice:
+			walk(v.Elem())
+		case *types.Array:
+			walk(v.Elem())
+		case *types.Map:
+			walk(v.Key())
+			walk(v.Elem())
+		case *types.Signature:
+			for i := 0; i < v.Params().Len(); i++ {
+				walk(v.Params().At(i).Type())
+			}
+			for i := 0; i < v.Results().Len(); i++ {
+				walk(v.Results().At(i).Type())
+			}
+		case *types.Struct:
+			for i := 0; i < v.NumFields(); i++ {
+				if field := v.Field(i); field.Exported() || field.Embedded() {
+					walk(field.Type())
+				}
+			}
+		case *types.Named:
+			if seen[v] {
+				return
+			}
+			seen[v] = true
+
+			for i := 0; i < v.TypeArgs().Len(); i++ {
+				walk(v.TypeArgs().At(i))
+			}
+			if _, ok := p.wellKnownType(v); ok || isTime(v) || isJsonRawMessage(v) {
+				return
+			}
+			if obj := v.Obj(); obj.Pkg() != nil && v.TypeArgs().Len() == 0 {
+				name := strings.ToLower(sanitizeTypeName(p.GoTypeNameToWebrpc(p.GoTypeName(v))))
+				if !containsTypeName(byName[name], obj) {
+					byName[name] = append(byName[name], obj)
+				}
+			}
+			walk(v.Underlying())
+		}
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		walk(iface.Method(i).Type())
+	}
+
+	p.qualifiedTypeNames = map[*types.TypeName]string{}
+	taken := map[string]bool{}
+	for name := range byName {
+		taken[name] = true
+	}
+
+	var names []string
+	for name, objs := range byName {
+		if len(objs) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		objs := byName[name]
+		sort.Slice(objs, func(i, j int) bool {
+			if a, b := objs[i].Pkg() == p.Pkg.Types, objs[j].Pkg() == p.Pkg.Types; a != b {
+				return a
+			}
+			return pkgImportPath(objs[i].Pkg()) < pkgImportPath(objs[j].Pkg())
+		})
+
+		for _, obj := range objs[1:] {
+			qualified := sanitizeTypeName(p.GoTypeNameToWebrpc(p.GoTypeName(obj.Type())))
+			dirs := strings.Split(path.Dir(pkgImportPath(obj.Pkg())), "/")
+			for i := len(dirs) - 1; i >= 0 && dirs[i] != "."; i-- {
+				qualified = sanitizeTypeName(dirs[i] + "." + qualified)
+				if !taken[strings.ToLower(qualified)] {
+					taken[strings.ToLower(qualified)] = true
+					p.qualifiedTypeNames[obj] = qualified
+					break
+				}
+			}
+		}
+	}
+}
+
+func containsTypeName(objs []*types.TypeName, obj *types.TypeName) bool {
+	for _, o := range objs {
+		if o == obj {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the type name given by the //gospeak:name directive, so the type can be renamed
+// in Go without breaking the clients, ie. `Pet` for:
+//
+//	//gospeak:name Pet
+//	type PetV2 struct{}
+func (p *Parser) typeNameDirective(obj *types.TypeName) (string, bool, error) {
+	name, ok := p.getDirective(obj, "//gospeak:name")
+	if !ok {
+		return "", false, nil
+	}
+
+	if !token.IsIdentifier(name) {
+		return "", false, fmt.Errorf("%v: %v: invalid //gospeak:name directive %q, expected type name", p.Pkg.Fset.Position(obj.Pos()), obj.Name(), name)
+	}
+	if p.isTypeNameTaken(name) {
+		return "", false, fmt.Errorf("%v: %v: type name %v given by //gospeak:name directive is already taken", p.Pkg.Fset.Position(obj.Pos()), obj.Name(), name)
+	}
+	p.TypeNames[strings.ToLower(name)] = struct{}{}
+
+	return name, true, nil
+}
+
+// Type names are case-insensitive in webrpc.
+func (p *Parser) isTypeNameTaken(name string) bool {
+	if _, ok := p.TypeNames[strings.ToLower(name)]; ok {
+		return true
+	}
+	return p.Schema.GetTypeByName(name) != nil
+}
+
+// Drops characters not allowed in webrpc type names and capitalizes the following letter,
+// ie. `Page[pkg.Item]` => `PagePkgItem`.
+func sanitizeTypeName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			upper = b.Len() > 0
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
 		}
+		b.WriteRune(r)
 	}
-	return 0
+	return b.String()
+}
+
+// Resolves type aliases, including the ones nested in pointer, slice, array and map types.
+// Well-known aliases, ie. any or json.RawMessage, are kept.
+func unaliasAll(typ types.Type) types.Type {
+	if _, ok := wellKnownAlias(typ); ok {
+		return typ
+	}
+	switch v := unalias(typ).(type) {
+	case *types.Pointer:
+		return types.NewPointer(unaliasAll(v.Elem()))
+	case *types.Slice:
+		return types.NewSlice(unaliasAll(v.Elem()))
+	case *types.Array:
+		return types.NewArray(unaliasAll(v.Elem()), v.Len())
+	case *types.Map:
+		return types.NewMap(unaliasAll(v.Key()), unaliasAll(v.Elem()))
+	default:
+		return v
+	}
+}
+
+// Returns true if the given type is json.RawMessage. Since encoding/json v2, it's an alias
+// of jsontext.Value, so the unaliased type is matched too.
+func isJsonRawMessage(typ *types.Named) bool {
+	pkg := typ.Obj().Pkg()
+	if pkg == nil {
+		return false
+	}
+	switch pkg.Path() + "." + typ.Obj().Name() {
+	case "encoding/json.RawMessage", "encoding/json/jsontext.Value":
+		return true
+	}
+	return false
+}
+
+// Returns the value type of nullable database/sql types, ie. string for sql.NullString
+// and T for sql.Null[T], or nil if the given type is not one of them.
+func sqlNullValueType(typ *types.Named) types.Type {
+	pkg := typ.Obj().Pkg()
+	if pkg == nil || pkg.Path() != "database/sql" || !strings.HasPrefix(typ.Obj().Name(), "Null") {
+		return nil
+	}
+
+	// struct { String string; Valid bool }
+	structTyp, ok := typ.Underlying().(*types.Struct)
+	if !ok || structTyp.NumFields() != 2 || structTyp.Field(1).Name() != "Valid" {
+		return nil
+	}
+
+	return structTyp.Field(0).Type()
+}
+
+// Returns true if the given type is time.Duration.
+func isDuration(typ *types.Named) bool {
+	pkg := typ.Obj().Pkg()
+	return pkg != nil && pkg.Path() == "time" && typ.Obj().Name() == "Duration"
+}
+
+// Returns true if the given type is time.Time.
+func isTime(typ *types.Named) bool {
+	pkg := typ.Obj().Pkg()
+	return pkg != nil && pkg.Path() == "time" && typ.Obj().Name() == "Time"
 }
 
 func firstToLower(s string) string {
@@ -85,3 +471,15 @@ func firstToLower(s string) string {
 	}
 	return string(lower) + s[size:]
 }
+
+func firstToUpper(s string) string {
+	orig, size := utf8.DecodeRuneInString(s)
+	if orig == utf8.RuneError && size <= 1 {
+		return s
+	}
+	upper := unicode.ToUpper(orig)
+	if orig == upper {
+		return s
+	}
+	return string(upper) + s[size:]
+}
@@ -0,0 +1,41 @@
+package parser
+
+import "testing"
+
+func TestSanitizeTypeName(t *testing.T) {
+	tt := []struct {
+		in  string
+		out string
+	}{
+		{in: "Pet", out: "Pet"},
+		{in: "emptyStruct", out: "emptyStruct"},
+		{in: "AnonymousField", out: "AnonymousField"},
+		{in: "Page[Item]", out: "PageItem"},
+		{in: "Page[pkg.Item]", out: "PagePkgItem"},
+		{in: "Map[string,int64]", out: "MapStringInt64"},
+		{in: "snake_case", out: "snake_case"},
+	}
+	for _, tc := range tt {
+		if got := sanitizeTypeName(tc.in); got != tc.out {
+			t.Errorf("sanitizeTypeName(%q): expected %q, got %q", tc.in, tc.out, got)
+		}
+	}
+}
+
+func TestVendorlessPath(t *testing.T) {
+	tt := []struct {
+		in  string
+		out string
+	}{
+		{in: "github.com/acme/app/model", out: "github.com/acme/app/model"},
+		{in: "github.com/acme/app/vendor/github.com/google/uuid", out: "github.com/google/uuid"},
+		{in: "app/vendor/github.com/acme/lib/vendor/github.com/google/uuid", out: "github.com/google/uuid"},
+		{in: "vendor/golang.org/x/net/http2", out: "golang.org/x/net/http2"},
+		{in: "github.com/acme/vendors", out: "github.com/acme/vendors"},
+	}
+	for _, tc := range tt {
+		if got := vendorlessPath(tc.in); got != tc.out {
+			t.Errorf("vendorlessPath(%q): expected %q, got %q", tc.in, tc.out, got)
+		}
+	}
+}
@@ -2,26 +2,161 @@ package parser
 
 import (
 	"fmt"
+	"go/ast"
+	"go/token"
 	"go/types"
 	"strings"
 
 	"github.com/webrpc/webrpc/schema"
 )
 
+// Parses the interface into the services of p.Schema.
+//
+// The types are parsed sequentially. The parser shares its state across the types,
+// ie. p.Schema.Types, p.ParsedTypes or p.parsingTypes, and the parsing time grows
+// linearly with the number of types, see BenchmarkParseInterface. Loading the packages
+// takes most of the time anyway.
 func (p *Parser) ParseInterfaceMethods(iface *types.Interface, name string) error {
+	// Interface's doc comment describes the service. The //go:webrpc directives are dropped.
+	var comments []string
+	if obj := p.Pkg.Types.Scope().Lookup(name); obj != nil {
+		comments = p.getDocComments(obj)
+	}
+
+	// Methods of embedded interfaces are flattened into the service, ie.
+	// type API interface { ReadAPI; WriteAPI }, unless they're split into
+	// separate services by the //gospeak:service directive.
+	p.warnDuplicateMethods(iface, name)
+
+	services, err := p.embeddedServices(name)
+	if err != nil {
+		return err
+	}
+
+	splitMethods := map[string]bool{}
+	for _, svc := range services {
+		for i := 0; i < svc.iface.NumMethods(); i++ {
+			splitMethods[svc.iface.Method(i).Name()] = true
+		}
+	}
+
+	p.qualifyCollidingTypes(iface)
+
+	if err := p.parseService(iface, name, comments, splitMethods); err != nil {
+		return err
+	}
+	for _, svc := range services {
+		for _, service := range p.Schema.Services {
+			if strings.EqualFold(service.Name, svc.name) {
+				return fmt.Errorf("%v: service name %v given by //gospeak:service directive is already taken", p.Pkg.Fset.Position(svc.pos), svc.name)
+			}
+		}
+		if err := p.parseService(svc.iface, svc.name, svc.comments, nil); err != nil {
+			return err
+		}
+	}
+
+	// Types are appended in the traversal order, which changes with unrelated edits.
+	// Sort them, so the generated code is reproducible and produces small diffs.
+	p.sortSchema()
+
+	return nil
+}
+
+// Embedded interface split into a separate service of the same schema.
+type embeddedService struct {
+	name     string
+	comments []string
+	iface    *types.Interface
+	pos      token.Pos
+}
+
+// Returns the embedded interfaces marked by the //gospeak:service directive, which
+// become separate services sharing the schema types, ie. AdminAPI and PublicAPI of:
+//
+//	type API interface {
+//		//gospeak:service
+//		AdminAPI
+//
+//		//gospeak:service Public
+//		PublicAPI
+//	}
+//
+// The service is named after the embedded interface, unless the directive gives the name.
+func (p *Parser) embeddedServices(name string) ([]*embeddedService, error) {
+	obj, ok := p.Pkg.Types.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, nil
+	}
+	_, typeSpec := p.getTypeSpec(obj)
+	if typeSpec == nil {
+		return nil, nil
+	}
+	ifaceType, ok := typeSpec.Type.(*ast.InterfaceType)
+	if !ok {
+		return nil, nil
+	}
+
+	var services []*embeddedService
+	for _, field := range ifaceType.Methods.List {
+		serviceName, ok := directiveArg(field.Doc, "//gospeak:service")
+		if !ok || len(field.Names) > 0 {
+			continue
+		}
+
+		embedded := p.Pkg.TypesInfo.TypeOf(field.Type)
+		named, ok := unalias(embedded).(*types.Named)
+		if !ok {
+			return nil, fmt.Errorf("%v: //gospeak:service directive requires a named interface, got %v", p.Pkg.Fset.Position(field.Pos()), embedded)
+		}
+		embeddedIface, ok := named.Underlying().(*types.Interface)
+		if !ok {
+			return nil, fmt.Errorf("%v: //gospeak:service directive requires a named interface, got %v", p.Pkg.Fset.Position(field.Pos()), embedded)
+		}
+		if serviceName == "" {
+			serviceName = named.Obj().Name()
+		}
+		if !token.IsIdentifier(serviceName) {
+			return nil, fmt.Errorf("%v: invalid //gospeak:service directive %q, expected service name", p.Pkg.Fset.Position(field.Pos()), serviceName)
+		}
+
+		services = append(services, &embeddedService{
+			name:     serviceName,
+			comments: p.getDocComments(named.Obj()),
+			iface:    embeddedIface,
+			pos:      field.Pos(),
+		})
+	}
+
+	return services, nil
+}
+
+// Parses the interface methods into a new service, except for the skipped methods.
+func (p *Parser) parseService(iface *types.Interface, name string, comments []string, skipMethods map[string]bool) error {
 	service := &schema.Service{
-		Name:   name,
-		Schema: p.Schema, // denormalize/back-reference
+		Name:     name,
+		Comments: comments,
+		Schema:   p.Schema, // denormalize/back-reference
 	}
 
+	// Exposed method names (lowercased) and their Go methods, see //gospeak:name.
+	methodNames := map[string]string{}
+
 	// Loop over the interface's methods.
 	for i := 0; i < iface.NumMethods(); i++ {
 		method := iface.Method(i)
-		if !method.Exported() {
+		if !method.Exported() || skipMethods[method.Name()] {
+			continue
+		}
+
+		// Internal-only methods, excluded from the schema by the //gospeak:ignore directive.
+		if _, ok := p.getDirective(method, "//gospeak:ignore"); ok {
 			continue
 		}
 
 		methodName := method.Id()
+		p.RefChain, p.RefPos = nil, nil
+		p.pushRef(fmt.Sprintf("%v.%v()", name, methodName), method.Pos())
 
 		methodSignature, ok := method.Type().(*types.Signature)
 		if !ok {
@@ -29,41 +164,113 @@ func (p *Parser) ParseInterfaceMethods(iface *types.Interface, name string) erro
 		}
 
 		methodParams := methodSignature.Params()
-		inputs, err := p.getMethodArguments(methodParams, true)
-		if err != nil {
-			return fmt.Errorf("%v(): failed to get inputs: %w", methodName, err)
-		}
 
-		// First method argument must be of type context.Context.
+		// First method argument must be of type context.Context, unless p.AllowNoContext is set.
+		noContext := false
 		if methodParams.Len() == 0 {
-			return fmt.Errorf("%v(): first method argument must be context.Context: no arguments defined", methodName)
+			if !p.AllowNoContext {
+				return fmt.Errorf("%v: %v(): first method argument must be context.Context: no arguments defined", p.Pkg.Fset.Position(method.Pos()), methodName)
+			}
+			noContext = true
+		} else if err := ensureContextType(methodParams.At(0).Type()); err != nil {
+			if !p.AllowNoContext {
+				return fmt.Errorf("%v: %v(): first method argument must be context.Context: %w", p.Pkg.Fset.Position(method.Pos()), methodName, err)
+			}
+			noContext = true
+		}
+		if !noContext {
+			// Cut it off before parsing the arguments, so --strict doesn't reject the context.Context
+			// interface. The gen/golang adds context.Context as first method argument automatically.
+			methodParams = subTuple(methodParams, 1, methodParams.Len())
 		}
-		if err := ensureContextType(methodParams.At(0).Type()); err != nil {
-			return fmt.Errorf("%v(): first method argument must be context.Context: %w", methodName, err)
+		inputs, err := p.getMethodArguments(methodName, methodParams, true)
+		if err != nil {
+			return fmt.Errorf("%v(): failed to get inputs: %w", methodName, err)
 		}
-		inputs = inputs[1:] // Cut it off. The gen/golang adds context.Context as first method argument automatically.
 
 		methodResults := methodSignature.Results()
-		outputs, err := p.getMethodArguments(methodResults, false)
+		stream, err := streamOutput(methodResults)
 		if err != nil {
-			return fmt.Errorf("%v(): failed to get outputs: %w", methodName, err)
+			return fmt.Errorf("%v: %v(): %w", p.Pkg.Fset.Position(method.Pos()), methodName, err)
 		}
 
 		// Last method return value must be of type error.
 		if methodResults.Len() == 0 {
-			return fmt.Errorf("%v(): last return value must be context.Context: no return values defined", methodName)
+			return fmt.Errorf("%v: %v(): last return value must be error: no return values defined", p.Pkg.Fset.Position(method.Pos()), methodName)
 		}
 		if err := ensureErrorType(methodResults.At(methodResults.Len() - 1).Type()); err != nil {
-			return fmt.Errorf("%v(): first method argument must be context.Context: %w", methodName, err)
+			return fmt.Errorf("%v: %v(): last return value must be error: %w", p.Pkg.Fset.Position(method.Pos()), methodName, err)
+		}
+		// Cut it off. The gen/golang adds error as a last return value automatically.
+		outputs, err := p.getMethodArguments(methodName, subTuple(methodResults, 0, methodResults.Len()-1), false)
+		if err != nil {
+			return fmt.Errorf("%v(): failed to get outputs: %w", methodName, err)
 		}
-		outputs = outputs[:len(outputs)-1] // Cut it off. The gen/golang adds error as a last return value automatically.
 
-		service.Methods = append(service.Methods, &schema.Method{
-			Name:    methodName,
-			Inputs:  inputs,
-			Outputs: outputs,
-			Service: service, // denormalize/back-reference
-		})
+		serviceMethod := &schema.Method{
+			Name:         methodName,
+			Comments:     p.getDocComments(method), // Resolves methods embedded from other pkgs too.
+			Inputs:       inputs,
+			Outputs:      outputs,
+			StreamOutput: stream != "",
+			Service:      service, // denormalize/back-reference
+		}
+		p.setMethodSource(serviceMethod, method.Pos())
+
+		// Method exposed under a different name, so it can be renamed in Go without breaking the clients.
+		if name, ok := p.getDirective(method, "//gospeak:name"); ok {
+			if !token.IsIdentifier(name) {
+				return fmt.Errorf("%v: %v(): invalid //gospeak:name directive %q, expected method name", p.Pkg.Fset.Position(method.Pos()), methodName, name)
+			}
+			serviceMethod.Name = name
+			if serviceMethod.Annotations == nil {
+				serviceMethod.Annotations = schema.Annotations{}
+			}
+			serviceMethod.Annotations["go.method.name"] = &schema.Annotation{
+				AnnotationType: "go.method.name",
+				Value:          methodName,
+			}
+		}
+		// Generators route the methods by name, so the exposed names must be unique, case-insensitively.
+		if other, ok := methodNames[strings.ToLower(serviceMethod.Name)]; ok {
+			return fmt.Errorf("%v: %v(): method name %v is already taken by %v(), check the //gospeak:name directives", p.Pkg.Fset.Position(method.Pos()), methodName, serviceMethod.Name, other)
+		}
+		methodNames[strings.ToLower(serviceMethod.Name)] = methodName
+
+		// Tell the generators not to pass the context through, ie. Version() (string, error).
+		if noContext {
+			if serviceMethod.Annotations == nil {
+				serviceMethod.Annotations = schema.Annotations{}
+			}
+			serviceMethod.Annotations["go.context"] = &schema.Annotation{
+				AnnotationType: "go.context",
+				Value:          "none",
+			}
+		}
+
+		// Tell the generators how to read the streamed values, ie. range over iter.Seq.
+		if stream != "" {
+			if serviceMethod.Annotations == nil {
+				serviceMethod.Annotations = schema.Annotations{}
+			}
+			serviceMethod.Annotations["go.stream"] = &schema.Annotation{
+				AnnotationType: "go.stream",
+				Value:          stream,
+			}
+		}
+
+		// Go convention, ie. "Deprecated: Use GetPetV2() instead."
+		if isDeprecated(serviceMethod.Comments) {
+			if serviceMethod.Annotations == nil {
+				serviceMethod.Annotations = schema.Annotations{}
+			}
+			serviceMethod.Annotations["deprecated"] = &schema.Annotation{
+				AnnotationType: "deprecated",
+				Value:          "true",
+			}
+		}
+
+		service.Methods = append(service.Methods, serviceMethod)
 	}
 
 	if len(service.Methods) == 0 {
@@ -72,41 +279,89 @@ func (p *Parser) ParseInterfaceMethods(iface *types.Interface, name string) erro
 	}
 
 	p.Schema.Services = append(p.Schema.Services, service)
+
 	return nil
 }
 
-func (p *Parser) getMethodArguments(params *types.Tuple, isInput bool) ([]*schema.MethodArgument, error) {
-	var args []*schema.MethodArgument
+// Warns about methods declared by more than one of the composed interfaces. Go merges
+// identical methods silently, so it's easy to lose track of which API owns the method.
+func (p *Parser) warnDuplicateMethods(iface *types.Interface, name string) {
+	declaredBy := map[string][]string{}
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		method := iface.ExplicitMethod(i)
+		declaredBy[method.Name()] = append(declaredBy[method.Name()], name)
+	}
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		embedded := iface.EmbeddedType(i)
+		embeddedIface, ok := embedded.Underlying().(*types.Interface)
+		if !ok {
+			continue
+		}
+		for j := 0; j < embeddedIface.NumMethods(); j++ {
+			method := embeddedIface.Method(j)
+			declaredBy[method.Name()] = append(declaredBy[method.Name()], p.GoTypeName(embedded))
+		}
+	}
+
+	var pos token.Pos
+	if obj := p.Pkg.Types.Scope().Lookup(name); obj != nil {
+		pos = obj.Pos()
+	}
+
+	for i := 0; i < iface.NumMethods(); i++ { // Sorted by name.
+		method := iface.Method(i)
+		if interfaces := declaredBy[method.Name()]; len(interfaces) > 1 {
+			p.Warnf(pos, "%v.%v() is declared by multiple interfaces: %v", name, method.Name(), strings.Join(interfaces, ", "))
+		}
+	}
+}
+
+func (p *Parser) getMethodArguments(methodName string, params *types.Tuple, isInput bool) ([]*schema.MethodArgument, error) {
+	args := []*schema.MethodArgument{}
+	derivedNames := map[string]types.Type{}
 
 	for i := 0; i < params.Len(); i++ {
 		param := params.At(i)
-		typ := param.Type()
+		typ := unaliasAll(param.Type())
+		if valueType, _ := streamValueType(typ); valueType != nil && !isInput {
+			typ = unaliasAll(valueType) // Stream of values, see streamOutput().
+		}
+		anonymousStruct := isAnonymousStruct(typ)
 
 		name := param.Name()
-		if name == "" {
+		if name == "" || name == "_" {
 			// If the argument's name is not defined, come up with a name based on its type.
-			// *pkg.User => user
-			// []*pkg.User => userList
-			// []string => stringList
-
-			name = typ.String()
-			name = name[findFirstLetter(name):]
-			if i := strings.LastIndex(name, "."); i > 0 {
-				name = name[i+1:]
+			name = argNameFromType(methodName, typ)
+			if isInput {
+				name += "Req"
 			}
-			name = firstToLower(name)
 
-			switch typ.(type) {
-			case *types.Slice, *types.Array:
-				name += "List"
+			// Unnamed arguments of the same type, ie. Compare(context.Context, *Pet, *Pet).
+			if prevTyp, ok := derivedNames[name]; ok {
+				qualifier := types.RelativeTo(p.Pkg.Types)
+				return nil, fmt.Errorf("%v: unnamed arguments %v and %v would both be sent as %q, name them explicitly", p.Pkg.Fset.Position(param.Pos()), types.TypeString(prevTyp, qualifier), types.TypeString(param.Type(), qualifier), name)
 			}
+			derivedNames[name] = param.Type()
+		}
 
-			if isInput {
-				name += "Req"
-			}
+		if err := p.checkUnsupportedType(param.Pos(), name, typ); err != nil {
+			return nil, err
 		}
 
-		varType, err := p.ParseType(typ) // Type name will be resolved deeper down the stack.
+		p.pushRef(name, param.Pos())
+		typeName := "" // Type name will be resolved deeper down the stack.
+		if anonymousStruct {
+			switch {
+			case param.Name() != "" && param.Name() != "_":
+				typeName = methodName + firstToUpper(name) + "Anonymous" // Filter(ctx, opts struct{...}) => FilterOptsAnonymous
+			case isInput:
+				typeName = methodName + "ReqAnonymous"
+			default:
+				typeName = methodName + "RespAnonymous"
+			}
+		}
+		varType, err := p.ParseNamedType(typeName, typ)
+		p.popRef()
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse argument %v %v: %w", name, typ, err)
 		}
@@ -116,6 +371,12 @@ func (p *Parser) getMethodArguments(params *types.Tuple, isInput bool) ([]*schem
 			optional = true // TODO: SHould we use varType.Struct.Type.Optional instead?
 		}
 
+		// Pointers are optional, same as struct fields. Check the Go type, since well-known
+		// types like *time.Time or *uuid.UUID are parsed as core types, ie. timestamp.
+		if _, ok := typ.(*types.Pointer); ok {
+			optional = true
+		}
+
 		arg := &schema.MethodArgument{
 			Name:      name,
 			Type:      varType,
@@ -124,14 +385,120 @@ func (p *Parser) getMethodArguments(params *types.Tuple, isInput bool) ([]*schem
 			Optional:  optional,
 		}
 
+		// Arguments sent as any, ie. map[string]any or json.RawMessage, keep their Go type
+		// in the same meta as struct fields, so the generated code can use it instead of
+		// interface{}. Other arguments don't need it, their Go type follows the webrpc type.
+		if hasAnyType(varType) {
+			arg.TypeExtra.Meta = []schema.TypeFieldMeta{{"go.field.type": p.goTypeExpr(typ)}}
+			if goImport := p.GoTypeImport(typ); goImport != "" {
+				arg.TypeExtra.Meta = append(arg.TypeExtra.Meta, schema.TypeFieldMeta{"go.type.import": goImport})
+			}
+		}
+
 		args = append(args, arg)
 	}
 
 	return args, nil
 }
 
+// Returns the variables of the tuple in the [from, to) range.
+func subTuple(tuple *types.Tuple, from, to int) *types.Tuple {
+	vars := make([]*types.Var, 0, to-from)
+	for i := from; i < to; i++ {
+		vars = append(vars, tuple.At(i))
+	}
+	return types.NewTuple(vars...)
+}
+
+// Returns name of the unnamed method argument derived from its type, ie.:
+//
+//	*pkg.User => user
+//	[]*pkg.User => userList
+//	map[string]int64 => int64Map
+//	Page[Pet] => page
+//	uuid.UUID => uuid
+//	interface{} => any
+//	struct{...} => filter (method name)
+func argNameFromType(methodName string, typ types.Type) string {
+	switch v := unalias(typ).(type) {
+	case *types.Pointer:
+		return argNameFromType(methodName, v.Elem())
+	case *types.Slice:
+		return argNameFromType(methodName, v.Elem()) + "List"
+	case *types.Array:
+		return argNameFromType(methodName, v.Elem()) + "List"
+	case *types.Map:
+		return argNameFromType(methodName, v.Elem()) + "Map"
+	case *types.Named:
+		return toCamelCase(v.Obj().Name())
+	case *types.Basic:
+		return v.Name()
+	case *types.Interface:
+		return "any"
+	case *types.Struct:
+		return firstToLower(methodName)
+	default:
+		return "arg"
+	}
+}
+
+// Returns the kind of the stream ("chan", "iter.Seq" or "iter.Seq2"), if the method streams
+// its results to the client, ie. (events <-chan *Event, err error) or (iter.Seq[*Event], error).
+// Each value received from the channel or iterator is sent as a single stream message.
+func streamOutput(results *types.Tuple) (string, error) {
+	var stream string
+	var streams int
+	for i := 0; i < results.Len(); i++ {
+		typ := unalias(results.At(i).Type())
+		valueType, kind := streamValueType(typ)
+		if kind == "" {
+			continue
+		}
+		if ch, ok := typ.(*types.Chan); ok && ch.Dir() == types.SendOnly {
+			return "", fmt.Errorf("streaming method can't return send-only channel %v, use <-chan instead", ch)
+		}
+		if valueType == nil {
+			return "", fmt.Errorf("streaming method can't return %v, use iter.Seq2[V, error] or iter.Seq[V] instead", typ)
+		}
+		stream = kind
+		streams++
+	}
+	if streams == 0 {
+		return "", nil
+	}
+	if streams != 1 || results.Len() != 2 {
+		return "", fmt.Errorf("streaming method must return a single channel or iterator and error, ie. (events <-chan *Event, err error)")
+	}
+
+	return stream, nil
+}
+
+// Returns the type of the streamed values and the kind of the stream, ie. *Event and "chan"
+// for <-chan *Event, or *Event and "iter.Seq2" for iter.Seq2[*Event, error]. Errors yielded
+// by iter.Seq2 end the stream. The value type is nil for unsupported iter.Seq2[K, V].
+func streamValueType(typ types.Type) (types.Type, string) {
+	switch v := unalias(typ).(type) {
+	case *types.Chan:
+		return v.Elem(), "chan"
+	case *types.Named:
+		if pkg := v.Obj().Pkg(); pkg == nil || pkg.Path() != "iter" {
+			return nil, ""
+		}
+		switch v.Obj().Name() {
+		case "Seq":
+			return v.TypeArgs().At(0), "iter.Seq"
+		case "Seq2":
+			if ensureErrorType(v.TypeArgs().At(1)) != nil {
+				return nil, "iter.Seq2"
+			}
+			return v.TypeArgs().At(0), "iter.Seq2"
+		}
+	}
+	return nil, ""
+}
+
 func ensureContextType(typ types.Type) (err error) {
-	namedType, ok := typ.(*types.Named)
+	namedType, ok := unalias(typ).(*types.Named)
 	if !ok {
 		return fmt.Errorf("expected named type: found type %T (%+v)", typ, typ)
 	}
@@ -140,18 +507,20 @@ func ensureContextType(typ types.Type) (err error) {
 		return fmt.Errorf("expected underlying interface: found type %T (%+v)", typ, typ)
 	}
 
-	pkgName := namedType.Obj().Pkg().Name()
-	typeName := namedType.Obj().Name()
-
-	if pkgName != "context" && typeName != "Context" {
-		return fmt.Errorf("expected context.Context: found %v.%v", pkgName, typeName)
+	// Universe types, ie. error, have no package.
+	obj := namedType.Obj()
+	if obj.Pkg() == nil {
+		return fmt.Errorf("expected context.Context: found %v", obj.Name())
+	}
+	if obj.Pkg().Path() != "context" || obj.Name() != "Context" {
+		return fmt.Errorf("expected context.Context: found %v.%v", obj.Pkg().Path(), obj.Name())
 	}
 
 	return nil
 }
 
 func ensureErrorType(typ types.Type) (err error) {
-	namedType, ok := typ.(*types.Named)
+	namedType, ok := unalias(typ).(*types.Named)
 	if !ok {
 		return fmt.Errorf("expected named type: found type %T (%+v)", typ, typ)
 	}
@@ -169,3 +538,12 @@ func ensureErrorType(typ types.Type) (err error) {
 
 	return nil
 }
+
+// Reports whether the type is an anonymous struct or a pointer to it, ie. *struct{ Limit int }.
+func isAnonymousStruct(typ types.Type) bool {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = unalias(ptr.Elem())
+	}
+	_, ok := typ.(*types.Struct)
+	return ok
+}
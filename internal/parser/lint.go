@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/webrpc/webrpc/schema"
+)
+
+// Schema lint rules, see Lint().
+var LintRules = map[string]string{
+	"no-any":           "reject any types, incl. elements of lists and maps",
+	"no-map-results":   "reject methods returning map<...> values",
+	"require-comments": "require descriptions of services, methods, structs and enums",
+}
+
+// Lint checks the finished schema against the given rules, ie. "no-any", so platform teams
+// can enforce the API hygiene before any code is generated. Returns all the violations.
+func Lint(s *schema.WebRPCSchema, rules []string) error {
+	enabled := map[string]bool{}
+	for _, rule := range rules {
+		if _, ok := LintRules[rule]; !ok {
+			return fmt.Errorf("unknown lint rule %q", rule)
+		}
+		enabled[rule] = true
+	}
+
+	var violations []string
+	report := func(rule string, format string, args ...interface{}) {
+		violations = append(violations, fmt.Sprintf("%v: %v", rule, fmt.Sprintf(format, args...)))
+	}
+
+	for _, typ := range s.Types {
+		if enabled["require-comments"] && len(typ.Comments) == 0 {
+			report("require-comments", "%v %v has no description", typ.Kind, typ.Name)
+		}
+		if typ.Kind != schema.TypeKind_Struct {
+			continue
+		}
+		for _, field := range typ.Fields {
+			if enabled["no-any"] && hasAnyType(field.Type) {
+				report("no-any", "field %v.%v is %v", typ.Name, field.Name, field.Type)
+			}
+		}
+	}
+
+	for _, service := range s.Services {
+		if enabled["require-comments"] && len(service.Comments) == 0 {
+			report("require-comments", "service %v has no description", service.Name)
+		}
+		for _, method := range service.Methods {
+			if enabled["require-comments"] && len(method.Comments) == 0 {
+				report("require-comments", "method %v.%v() has no description", service.Name, method.Name)
+			}
+			for _, in := range method.Inputs {
+				if enabled["no-any"] && hasAnyType(in.Type) {
+					report("no-any", "argument %v of %v.%v() is %v", in.Name, service.Name, method.Name, in.Type)
+				}
+			}
+			for _, out := range method.Outputs {
+				if enabled["no-any"] && hasAnyType(out.Type) {
+					report("no-any", "result %v of %v.%v() is %v", out.Name, service.Name, method.Name, out.Type)
+				}
+				if enabled["no-map-results"] && out.Type != nil && out.Type.Type == schema.T_Map {
+					report("no-map-results", "result %v of %v.%v() is %v", out.Name, service.Name, method.Name, out.Type)
+				}
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("schema %v has %v lint violation(s):\n  %v", s.SchemaName, len(violations), strings.Join(violations, "\n  "))
+	}
+
+	return nil
+}
+
+// Reports whether the type is any or a list/map of any.
+func hasAnyType(varType *schema.VarType) bool {
+	switch {
+	case varType == nil:
+		return false
+	case varType.Type == schema.T_Any:
+		return true
+	case varType.List != nil:
+		return hasAnyType(varType.List.Elem)
+	case varType.Map != nil:
+		return hasAnyType(varType.Map.Value)
+	}
+	return false
+}
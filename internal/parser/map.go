@@ -13,6 +13,12 @@ func (p *Parser) ParseMap(typeName string, m *types.Map) (*schema.VarType, error
 		return nil, fmt.Errorf("failed to parse map key type: %w", err)
 	}
 
+	// Named basic keys resolve to their underlying type, ie. map[UserID]Profile => map<int64,Profile>.
+	// JSON object keys must be strings or integers, or implement encoding.TextMarshaler.
+	if !isValidMapKey(m.Key(), key) {
+		return nil, p.refErrorf("unsupported map key type %v, expected string or integer", p.GoTypeName(m.Key()))
+	}
+
 	value, err := p.ParseNamedType(typeName, m.Elem())
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse map value type: %w", err)
@@ -29,3 +35,18 @@ func (p *Parser) ParseMap(typeName string, m *types.Map) (*schema.VarType, error
 
 	return varType, nil
 }
+
+// Reports whether the map key is supported by both webrpc and encoding/json.
+func isValidMapKey(typ types.Type, key *schema.VarType) bool {
+	if ptr, ok := unalias(typ).(*types.Pointer); ok && !isTextMarshaler(ptr, nil) {
+		return false // map[*UserID]Profile
+	}
+
+	for _, coreType := range schema.VarKeyCoreTypes {
+		if key.Type == coreType {
+			return true
+		}
+	}
+
+	return false
+}
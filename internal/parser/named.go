@@ -3,14 +3,36 @@ package parser
 import (
 	"fmt"
 	"go/types"
+	"strings"
 
 	"github.com/webrpc/webrpc/schema"
 )
 
 func (p *Parser) ParseNamedType(goTypeName string, typ types.Type) (varType *schema.VarType, err error) {
+	typ = unalias(typ) // type Alias = pkg.Type
+
+	if named, ok := typ.(*types.Named); ok && named.TypeArgs().Len() > 0 {
+		typ = p.canonicalInstance(named) // Page[Pet]
+	}
+
 	// On cache HIT, return a pointer to parsedType from cache.
 	if parsedType, ok := p.ParsedTypes[typ]; ok {
-		return parsedType, nil
+		if parsedType.Type != schema.T_Unknown {
+			return parsedType, nil
+		}
+
+		// The type is still being parsed. Structs fill in their placeholder before parsing the fields,
+		// so the recursion is fine if it passes through a struct, ie. type Tree map[string]*Node and
+		// type Node struct { Children Tree }. The placeholder is filled in once Tree is parsed.
+		// Otherwise, the named type is in a cycle of lists, maps or pointers, ie. type Tree map[string]Tree.
+		if _, ok := typ.(*types.Named); ok {
+			if p.isRecursiveStruct(typ) {
+				return parsedType, nil
+			}
+			return nil, p.typeCycleError(typ)
+		}
+		// Unnamed types, ie. []Tree[T], might be shared by the struct fields of
+		// different types, ie. type X Node[T] and Node[T]. Parse them again.
 	}
 
 	// On cache MISS, create new parsedType pointer and warm up the cache with it. Any subsequent/recursive
@@ -22,8 +44,10 @@ func (p *Parser) ParseNamedType(goTypeName string, typ types.Type) (varType *sch
 		Expr: goTypeName,
 	}
 	p.ParsedTypes[typ] = cacheDoNotReturn
+	p.parsingTypes = append(p.parsingTypes, typ)
 
 	defer func() {
+		p.parsingTypes = p.parsingTypes[:len(p.parsingTypes)-1]
 		if varType != nil {
 			*cacheDoNotReturn = *varType // Update the cache value via pointer dereference.
 			varType = cacheDoNotReturn
@@ -36,16 +60,52 @@ func (p *Parser) ParseNamedType(goTypeName string, typ types.Type) (varType *sch
 		underlying := v.Underlying()
 		goTypeName := p.GoTypeName(typ)
 
-		if pkg != nil {
-			if goTypeName == "time.Time" {
+		// Custom type mappings and well-known types, ie. big.Float or decimal.Decimal.
+		if varType, ok := p.wellKnownType(v); ok {
+			return varType, nil
+		}
+
+		// Well-known time.Time, incl. elements of []time.Time, map[string]time.Time etc.
+		if isTime(v) {
+			return &schema.VarType{
+				Expr: "timestamp",
+				Type: schema.T_Timestamp,
+			}, nil
+		}
+
+		// time.Duration, sent as integer number of units or as string, see p.Duration.
+		if isDuration(v) {
+			if p.Duration == "string" {
 				return &schema.VarType{
-					Expr: "timestamp",
-					Type: schema.T_Timestamp,
+					Expr: "string",
+					Type: schema.T_String,
 				}, nil
 			}
+			return &schema.VarType{
+				Expr: "int64",
+				Type: schema.T_Int64,
+			}, nil
 		}
 
-		if enum, ok := p.ParsedEnumTypes[typ.String()]; ok {
+		// Raw JSON passthrough, incl. elements of map[string]json.RawMessage etc.
+		if isJsonRawMessage(v) {
+			return &schema.VarType{
+				Expr: "any",
+				Type: schema.T_Any,
+			}, nil
+		}
+
+		// Nullable types, ie. sql.NullString => string or nullable.Nullable[T] => T.
+		// Struct fields of these types are optional.
+		if valueType := p.nullableValueType(v); valueType != nil {
+			return p.ParseNamedType(p.GoTypeName(valueType), valueType)
+		}
+
+		enum, err := p.lookupEnum(v)
+		if err != nil {
+			return nil, err
+		}
+		if enum != nil {
 			// TODO(webrpc): Currently, the enum.Type holds the underlying backend
 			// type (ie. int64) but instead we want the "string" type in JSON.
 			return &schema.VarType{
@@ -54,8 +114,9 @@ func (p *Parser) ParseNamedType(goTypeName string, typ types.Type) (varType *sch
 			}, nil
 		}
 
-		// If the type implements encoding.TextMarshaler, it's a string.
-		if isTextMarshaler(v, pkg) {
+		// If the type implements encoding.TextMarshaler, it's a string. Unless it implements
+		// json.Marshaler too, which encoding/json prefers, ie. big.Int is sent as a number.
+		if isTextMarshaler(v, pkg) && !isJsonMarshaller(v, pkg) {
 			return &schema.VarType{
 				Expr: "string",
 				Type: schema.T_String,
@@ -81,6 +142,9 @@ func (p *Parser) ParseNamedType(goTypeName string, typ types.Type) (varType *sch
 			// If the named type is a slice/array and implements json.Marshaler,
 			// we assume it's []any.
 			if isJsonMarshaller(v, pkg) {
+				if err := p.strictAny("%v implementing json.Marshaler", goTypeName); err != nil {
+					return nil, err
+				}
 				return &schema.VarType{
 					Expr: "[]any",
 					Type: schema.T_List,
@@ -93,6 +157,14 @@ func (p *Parser) ParseNamedType(goTypeName string, typ types.Type) (varType *sch
 				}, nil
 			}
 
+			// Named fixed-size byte array, ie. type Hash [32]byte.
+			if p.isByteArray(v) {
+				return &schema.VarType{
+					Expr: "string",
+					Type: schema.T_String,
+				}, nil
+			}
+
 			var elem types.Type
 			switch underlyingElem := u.(type) {
 			case *types.Slice:
@@ -122,13 +194,49 @@ func (p *Parser) ParseNamedType(goTypeName string, typ types.Type) (varType *sch
 
 		default:
 			if isJsonMarshaller(v, pkg) {
+				if err := p.strictAny("%v implementing json.Marshaler", goTypeName); err != nil {
+					return nil, err
+				}
 				return &schema.VarType{
 					Expr: "any",
 					Type: schema.T_Any,
 				}, nil
 			}
 
-			return p.ParseNamedType(goTypeName, underlying)
+			var (
+				varType *schema.VarType
+				meta    []schema.TypeFieldMeta
+			)
+			if structTyp, ok := underlying.(*types.Struct); ok {
+				if err := p.checkExportedStruct(v, goTypeName, structTyp); err != nil {
+					return nil, err
+				}
+
+				// Parse the struct right into the cached placeholder, so recursive references
+				// (ie. `Next *Page[T]`) see the final webrpc type, not the Go type name.
+				var webrpcTypeName string
+				webrpcTypeName, meta, err = p.namedWebrpcTypeName(v)
+				if err != nil {
+					return nil, err
+				}
+				varType, err = p.parseStruct(webrpcTypeName, goTypeName, structTyp, cacheDoNotReturn)
+			} else {
+				varType, err = p.ParseNamedType(goTypeName, underlying)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			// Named struct's doc comment describes the schema type.
+			if varType.Struct != nil && varType.Struct.Type != nil && varType.Struct.Type.Comments == nil {
+				varType.Struct.Type.Comments = p.getDocComments(v.Obj())
+			}
+			if varType.Struct != nil && varType.Struct.Type != nil {
+				varType.Struct.Type.Meta = append(varType.Struct.Type.Meta, meta...)
+				p.setTypeSource(varType.Struct.Type, v.Obj().Pos())
+			}
+
+			return varType, nil
 		}
 
 	case *types.Basic:
@@ -140,6 +248,9 @@ func (p *Parser) ParseNamedType(goTypeName string, typ types.Type) (varType *sch
 	case *types.Slice:
 		return p.ParseSlice(goTypeName, v)
 
+	case *types.Array:
+		return p.ParseArray(goTypeName, v)
+
 	case *types.Interface:
 		return p.ParseAny(goTypeName, v)
 
@@ -152,7 +263,60 @@ func (p *Parser) ParseNamedType(goTypeName string, typ types.Type) (varType *sch
 		}
 		return p.ParseNamedType(goTypeName, v.Elem())
 
+	case *types.Chan:
+		return nil, errChanType(v)
+
+	case *types.TypeParam:
+		return nil, p.refErrorf("uninstantiated type parameter %v", v)
+
 	default:
-		return nil, fmt.Errorf("unsupported argument type %T", typ)
+		return nil, p.refErrorf("unsupported type %v (%T)", typ, typ)
+	}
+}
+
+// Returns the first seen instance of the given generic type instantiated with the
+// same type arguments, ie. Page[Pet]. The type checker doesn't guarantee identical
+// instances to be the same pointer, so we key them by the fully qualified name.
+func (p *Parser) canonicalInstance(named *types.Named) types.Type {
+	key := named.String() // pkg/path.Page[pkg/path.Pet]
+	if instance, ok := p.instances[key]; ok {
+		return instance
 	}
+
+	if p.instances == nil {
+		p.instances = map[string]types.Type{}
+	}
+	p.instances[key] = named
+
+	return named
+}
+
+// Reports whether a struct is being parsed on the path from the type to itself.
+func (p *Parser) isRecursiveStruct(typ types.Type) bool {
+	for i := len(p.parsingTypes) - 1; i >= 0 && p.parsingTypes[i] != typ; i-- {
+		if _, ok := p.parsingTypes[i].Underlying().(*types.Struct); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns error describing the cycle of types leading back to the given type, which is
+// still being parsed, ie. "unsupported recursive type Tree, only structs can refer to
+// themselves: Tree -> Nodes -> Tree" for type Tree []Nodes and type Nodes map[string]Tree.
+func (p *Parser) typeCycleError(typ types.Type) error {
+	var cycle []string
+	for i := len(p.parsingTypes) - 1; i >= 0; i-- {
+		if p.parsingTypes[i] == typ {
+			for _, t := range p.parsingTypes[i:] {
+				if _, ok := t.(*types.Named); ok {
+					cycle = append(cycle, p.GoTypeName(t))
+				}
+			}
+			break
+		}
+	}
+	cycle = append(cycle, p.GoTypeName(typ))
+
+	return p.refErrorf("unsupported recursive type %v, only structs can refer to themselves: %v", p.GoTypeName(typ), strings.Join(cycle, " -> "))
 }
@@ -1,7 +1,11 @@
 package parser
 
 import (
+	"fmt"
+	"go/token"
 	"go/types"
+	"sort"
+	"strings"
 
 	"github.com/webrpc/webrpc/schema"
 	"golang.org/x/tools/go/packages"
@@ -19,12 +23,49 @@ type Parser struct {
 
 	ParsedEnumTypes map[string]*schema.Type // Helps lookup enum types by pkg easily.
 
+	TypeNames map[string]struct{} // Lowercased webrpc names of the parsed struct types, to keep them unique.
+
 	InlineMode    bool // When traversing `json:",inline"`, we don't want to store the struct type as WebRPC message.
 	ImportedPaths map[string]struct{}
 
 	SchemaPkgName string // Schema file's package name.
 
+	// Chain of references (method, argument, struct fields) leading to the type being parsed,
+	// ie. ["PetStore.GetPet()", "pet", "Pet.Owner"]. Used in error messages.
+	RefChain []string
+	RefPos   []token.Pos // Source positions of the RefChain references, see refErrorf().
+
+	SkipUnsupportedFields bool     // Omit func, chan and unsafe.Pointer struct fields with a warning, instead of failing.
+	Warnings              []string // Non-fatal issues found while parsing.
+
+	Strict bool // Reject types silently sent as any, ie. interfaces with methods and json.Marshaler types.
+
+	AllowNoContext bool // Accept methods without the context.Context argument, see @go.context annotation.
+
+	ConstEnums bool // Collect enums defined as a named type with a block of typed constants, see collectConstEnums().
+
+	Duration   string // Wire format of time.Duration values, ie. "ms" or "string". Defaults to "ns".
+	ByteArrays string // Wire format of fixed-size byte arrays, "hex" or "base64". Lists of numbers by default.
+	URLStrings bool   // Send url.URL as string. Structs by default, same as encoding/json.
+
+	InternalTypes string // Struct fields of internal package types, "error" or "copy". Imported as is by default.
+
+	FieldNames string // JSON names of untagged struct fields, "camelCase" or "snake_case". Go field names by default.
+
+	TypeMappings map[string]schema.CoreType // Custom mappings of Go types (ie. github.com/acme/money.Money) to webrpc types.
+
+	NullableTypes map[string]struct{} // Custom generic wrappers of nullable values (ie. github.com/acme/opt.Option), see nullableValueType().
+
+	Provenance bool // Record Go source positions of types and methods in {"go.source": "file.go:line"} meta/annotations.
+
 	Pkg *packages.Package
+
+	syntaxFiles        map[string]*syntaxFile            // Source files by filename, see getSyntaxFile().
+	instances          map[string]types.Type             // Instantiated generic types by their name, see canonicalInstance().
+	parsingTypes       []types.Type                      // Types being parsed, innermost last. Used to report type cycles, see typeCycleError().
+	fieldPos           map[*schema.TypeField]token.Pos   // Source positions of the parsed struct fields, see dominantFields().
+	structFields       map[*schema.Type][]fieldCandidate // Fields of the parsed structs before dominantFields(), flattened into the embedding structs.
+	qualifiedTypeNames map[*types.TypeName]string        // Names of the colliding types used by the service, see qualifyCollidingTypes().
 }
 
 func New(pkg *packages.Package) *Parser {
@@ -38,6 +79,7 @@ func New(pkg *packages.Package) *Parser {
 		ParsedTypes:     map[types.Type]*schema.VarType{},
 		Pkg:             pkg,
 		ParsedEnumTypes: map[string]*schema.Type{},
+		TypeNames:       map[string]struct{}{},
 
 		// TODO: Change this to map[*types.Package]string so we can rename duplicated pkgs?
 		ImportedPaths: map[string]struct{}{
@@ -46,3 +88,54 @@ func New(pkg *packages.Package) *Parser {
 		},
 	}
 }
+
+// Pushes the reference (method, argument or struct field) declared at the given position to RefChain.
+func (p *Parser) pushRef(name string, pos token.Pos) {
+	p.RefChain = append(p.RefChain, name)
+	p.RefPos = append(p.RefPos, pos)
+}
+
+func (p *Parser) popRef() {
+	p.RefChain = p.RefChain[:len(p.RefChain)-1]
+	p.RefPos = p.RefPos[:len(p.RefPos)-1]
+}
+
+// Returns error prefixed with the source position of the innermost reference and followed
+// by the chain of references, ie. "api.go:12:2: unsupported map key type *Key, expected
+// string or integer: PetStore.GetPet() => pet => Pet.Owners".
+func (p *Parser) refErrorf(format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	if len(p.RefChain) > 0 {
+		err = fmt.Errorf("%w: %v", err, strings.Join(p.RefChain, " => "))
+	}
+	for i := len(p.RefPos) - 1; i >= 0; i-- {
+		if p.RefPos[i].IsValid() {
+			return fmt.Errorf("%v: %w", p.Pkg.Fset.Position(p.RefPos[i]), err)
+		}
+	}
+	return err
+}
+
+// Warnf records a non-fatal issue found at the given source position.
+func (p *Parser) Warnf(pos token.Pos, format string, args ...interface{}) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf("%v: %v", p.Pkg.Fset.Position(pos), fmt.Sprintf(format, args...)))
+}
+
+// Sorts schema types by their kind (enums first) and name, and service methods by
+// their name, incl. the ones renamed by //gospeak:name. Struct fields and enum values
+// keep their declaration order. Services keep the order of the interface declaration.
+func (p *Parser) sortSchema() {
+	sort.SliceStable(p.Schema.Types, func(i, j int) bool {
+		a, b := p.Schema.Types[i], p.Schema.Types[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind // "enum" < "struct"
+		}
+		return a.Name < b.Name
+	})
+
+	for _, service := range p.Schema.Services {
+		sort.SliceStable(service.Methods, func(i, j int) bool {
+			return service.Methods[i].Name < service.Methods[j].Name
+		})
+	}
+}
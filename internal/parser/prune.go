@@ -0,0 +1,68 @@
+package parser
+
+import "github.com/webrpc/webrpc/schema"
+
+// PruneTypes removes the types not referenced by any of the service methods, directly or
+// via other types, ie. enums of the schema package or structs parsed only as a side effect,
+// so the generated clients don't carry any dead code. Constants, see CollectConsts(), are kept.
+func (p *Parser) PruneTypes() {
+	reachable := map[*schema.Type]bool{}
+
+	var visit func(varType *schema.VarType)
+	visit = func(varType *schema.VarType) {
+		if varType == nil {
+			return
+		}
+
+		switch varType.Type {
+		case schema.T_List:
+			visit(varType.List.Elem)
+			return
+		case schema.T_Map:
+			visit(varType.Map.Key)
+			visit(varType.Map.Value)
+			return
+		}
+
+		var typ *schema.Type
+		if varType.Struct != nil {
+			typ = varType.Struct.Type
+		} else if enum := p.Schema.GetTypeByName(varType.Expr); enum != nil && enum.Kind == schema.TypeKind_Enum {
+			typ = enum // Enums are referenced by name, see ParseNamedType().
+		}
+		if typ == nil || reachable[typ] {
+			return
+		}
+		reachable[typ] = true
+
+		for _, field := range typ.Fields {
+			visit(field.Type)
+		}
+	}
+
+	// Constants are exported for the clients, even if no method refers to them.
+	for _, typ := range p.Schema.Types {
+		if isConstsType(typ) {
+			reachable[typ] = true
+		}
+	}
+
+	for _, service := range p.Schema.Services {
+		for _, method := range service.Methods {
+			for _, arg := range method.Inputs {
+				visit(arg.Type)
+			}
+			for _, arg := range method.Outputs {
+				visit(arg.Type)
+			}
+		}
+	}
+
+	types := p.Schema.Types[:0]
+	for _, typ := range p.Schema.Types {
+		if reachable[typ] {
+			types = append(types, typ)
+		}
+	}
+	p.Schema.Types = types
+}
@@ -2,52 +2,136 @@ package parser
 
 import (
 	"go/types"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
-// This regex will return the following three submatches,
-// given `db:"id,omitempty,pk" json:"id,string"` struct tag:
-//
-//	[0]: json:"id,string"
-//	[1]: id
-//	[2]: ,string
-var jsonTagRegex, _ = regexp.Compile(`\s?json:\"([^,\"]*)(,[^\"]*)?\"`)
-
+// JSON struct tag, parsed the same way as encoding/json does, ie.
+// `json:"id,omitempty,string"` or `json:"-,"` for a field named "-".
 type JsonTag struct {
 	Name      string
 	Value     string
+	Ignored   bool // `json:"-"`
 	IsString  bool
 	Omitempty bool
 	Inline    bool
 }
 
 func GetJsonTag(structTags string) (JsonTag, bool) {
-	if !strings.Contains(structTags, `json:"`) {
+	// Lookup unquotes the value, ie. `json:"a\"b"` => a"b.
+	value, ok := reflect.StructTag(structTags).Lookup("json")
+	if !ok || value == "" {
 		return JsonTag{}, false
 	}
 
-	submatches := jsonTagRegex.FindStringSubmatch(structTags)
+	if value == "-" { // `json:"-,"` is a field named "-"
+		return JsonTag{Value: value, Ignored: true}, true
+	}
 
-	// Submatches from the jsonTagRegex:
-	// [0]: json:"deleted_by,omitempty,string"
-	// [1]: deleted_by
-	// [2]: ,omitempty,string
-	if len(submatches) != 3 {
-		return JsonTag{}, false
+	name, options, _ := strings.Cut(value, ",")
+	if !isValidJsonTagName(name) {
+		name = "" // encoding/json falls back to the Go field name
 	}
 
 	jsonTag := JsonTag{
-		Name:      submatches[1],
-		Value:     submatches[1] + submatches[2],
-		IsString:  strings.Contains(submatches[2], ",string"),
-		Omitempty: strings.Contains(submatches[2], ",omitempty"),
-		Inline:    strings.Contains(submatches[2], ",inline"),
+		Name:  name,
+		Value: value,
+	}
+	for options != "" {
+		var option string
+		option, options, _ = strings.Cut(options, ",")
+		switch option {
+		case "string":
+			jsonTag.IsString = true
+		case "omitempty":
+			jsonTag.Omitempty = true
+		case "inline":
+			jsonTag.Inline = true
+		}
 	}
 
 	return jsonTag, true
 }
 
+// Reports whether the JSON tag name is valid, same as in encoding/json.
+func isValidJsonTagName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but
+			// otherwise any punctuation chars are allowed
+			// in a tag name.
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+// Returns key/value pairs of the struct tag in the declaration order, ie.
+// `json:"id" db:"pet_id"` => [["json", "id"], ["db", "pet_id"]]. Same as
+// reflect.StructTag.Lookup(), the first of the duplicate keys wins and parsing
+// stops at the first malformed pair.
+func structTagPairs(structTags string) [][2]string {
+	var pairs [][2]string
+	seen := map[string]bool{}
+
+	tag := structTags
+	for tag != "" {
+		// Skip leading space.
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// Scan to colon. A space, a quote or a control character is a syntax error.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Scan quoted string to find value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		quoted := tag[:i+1]
+		tag = tag[i+1:]
+
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			break
+		}
+		if !seen[key] {
+			seen[key] = true
+			pairs = append(pairs, [2]string{key, value})
+		}
+	}
+
+	return pairs
+}
+
 var textMarshalerRegex = regexp.MustCompile(`^func \((.+)\)\.MarshalText\(\) \((.+ )?\[\]byte, ([a-z]+ )?error\)$`)
 var textUnmarshalerRegex = regexp.MustCompile(`^func \((.+)\)\.UnmarshalText\((.+ )?\[\]byte\) \(?(.+ )?error\)?$`)
 
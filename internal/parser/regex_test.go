@@ -26,6 +26,17 @@ func TestJsonTagRegex(t *testing.T) {
 		{in: `xxx:"X X X" json:"id,string" yyy:"Y Y Y"`, out: JsonTag{Name: "id", Value: "id,string", IsString: true}},
 		{in: `db:"id,omitempty,pk" json:"id,string"`, out: JsonTag{Name: "id", Value: "id,string", IsString: true}},
 		{in: `db:"id,omitempty,pk" json:"External_ID,string,omitempty" someOtherTag:"some,other:value"`, out: JsonTag{Name: "External_ID", Value: "External_ID,string,omitempty", IsString: true, Omitempty: true}},
+		{in: `json:"-"`, out: JsonTag{Value: "-", Ignored: true}},
+		{in: `json:"-,"`, out: JsonTag{Name: "-", Value: "-,"}},
+		{in: `json:"-,omitempty"`, out: JsonTag{Name: "-", Value: "-,omitempty", Omitempty: true}},
+		{in: `json:",omitempty"`, out: JsonTag{Value: ",omitempty", Omitempty: true}},
+		{in: `json:",inline"`, out: JsonTag{Value: ",inline", Inline: true}},
+		{in: `json:"id,stringify,omitemptyish"`, out: JsonTag{Name: "id", Value: "id,stringify,omitemptyish"}},
+		{in: `json:"a\"b,omitempty"`, out: JsonTag{Value: `a"b,omitempty`, Omitempty: true}}, // invalid name
+		{in: `json:"a\u00e9,omitempty"`, out: JsonTag{Name: "aé", Value: "aé,omitempty", Omitempty: true}},
+		{in: `json:"x-id,omitempty"`, out: JsonTag{Name: "x-id", Value: "x-id,omitempty", Omitempty: true}},
+		{in: `db:"json:\"nope\"" json:"id"`, out: JsonTag{Name: "id", Value: "id"}},
+		{in: `xjson:"id"`},
 	}
 	for _, tc := range tt {
 		jsonTag, ok := GetJsonTag(tc.in)
@@ -90,3 +101,24 @@ func TestJsonUnmarshalerRegex(t *testing.T) {
 		}
 	}
 }
+
+func TestStructTagPairs(t *testing.T) {
+	tt := []struct {
+		in  string
+		out [][2]string
+	}{
+		{in: ``},
+		{in: `whatever`},
+		{in: `json:"id"`, out: [][2]string{{"json", "id"}}},
+		{in: `json:"id,omitempty" db:"pet_id"  yaml:"id"`, out: [][2]string{{"json", "id,omitempty"}, {"db", "pet_id"}, {"yaml", "id"}}},
+		{in: `db:"a" db:"b"`, out: [][2]string{{"db", "a"}}},
+		{in: `db:"a\"b" validate:"required"`, out: [][2]string{{"db", `a"b`}, {"validate", "required"}}},
+		{in: `db:"a" broken yaml:"id"`, out: [][2]string{{"db", "a"}}},
+		{in: `db:"unterminated`},
+	}
+	for _, tc := range tt {
+		if got := structTagPairs(tc.in); !cmp.Equal(got, tc.out) {
+			t.Errorf("%s: %s", tc.in, cmp.Diff(tc.out, got))
+		}
+	}
+}
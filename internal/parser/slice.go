@@ -23,3 +23,51 @@ func (p *Parser) ParseSlice(typeName string, sliceTyp *types.Slice) (*schema.Var
 
 	return varType, nil
 }
+
+// Fixed-size arrays are lists in JSON, same as slices.
+func (p *Parser) ParseArray(typeName string, arrayTyp *types.Array) (*schema.VarType, error) {
+	if p.isByteArray(arrayTyp) {
+		return &schema.VarType{
+			Expr: "string",
+			Type: schema.T_String,
+		}, nil
+	}
+
+	elem, err := p.ParseNamedType(typeName, arrayTyp.Elem())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse array type: %w", err)
+	}
+
+	varType := &schema.VarType{
+		Expr: fmt.Sprintf("[]%v", elem.String()),
+		Type: schema.T_List,
+		List: &schema.VarListType{
+			Elem: elem,
+		},
+	}
+
+	return varType, nil
+}
+
+// Reports whether the given type is a fixed-size byte array (ie. [32]byte) sent
+// as string, see p.ByteArrays. Named arrays implementing encoding.TextMarshaler
+// or json.Marshaler are left alone.
+func (p *Parser) isByteArray(typ types.Type) bool {
+	if p.ByteArrays == "" {
+		return false
+	}
+
+	if named, ok := typ.(*types.Named); ok {
+		if isTextMarshaler(named, named.Obj().Pkg()) || isJsonMarshaller(named, named.Obj().Pkg()) {
+			return false
+		}
+	}
+
+	arrayTyp, ok := typ.Underlying().(*types.Array)
+	if !ok {
+		return false
+	}
+
+	basic, ok := arrayTyp.Elem().Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.Byte
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+
+	"github.com/webrpc/webrpc/schema"
+)
+
+// Returns "file.go:line" position of the given declaration, relative to the schema
+// package directory and with forward slashes, so it's stable across machines.
+func (p *Parser) sourcePosition(pos token.Pos) string {
+	position := p.Pkg.Fset.Position(pos)
+
+	filename := position.Filename
+	if len(p.Pkg.Syntax) > 0 {
+		dir := filepath.Dir(p.Pkg.Fset.Position(p.Pkg.Syntax[0].Package).Filename)
+		if rel, err := filepath.Rel(dir, filename); err == nil {
+			filename = rel
+		}
+	}
+
+	return fmt.Sprintf("%v:%v", filepath.ToSlash(filename), position.Line)
+}
+
+// Records the Go source position of the type declaration in {"go.source": "file.go:line"} meta.
+func (p *Parser) setTypeSource(typ *schema.Type, pos token.Pos) {
+	if !p.Provenance || !pos.IsValid() {
+		return
+	}
+
+	for _, meta := range typ.Meta {
+		if _, ok := meta["go.source"]; ok {
+			return // Types are reached multiple times via the cache.
+		}
+	}
+
+	typ.Meta = append(typ.Meta, schema.TypeFieldMeta{"go.source": p.sourcePosition(pos)})
+}
+
+// Records the Go source position of the method declaration in @go.source annotation.
+func (p *Parser) setMethodSource(method *schema.Method, pos token.Pos) {
+	if !p.Provenance || !pos.IsValid() {
+		return
+	}
+
+	if method.Annotations == nil {
+		method.Annotations = schema.Annotations{}
+	}
+	method.Annotations["go.source"] = &schema.Annotation{
+		AnnotationType: "go.source",
+		Value:          p.sourcePosition(pos),
+	}
+}
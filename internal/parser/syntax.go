@@ -0,0 +1,204 @@
+package parser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Source file, along with the file set its positions belong to.
+type syntaxFile struct {
+	file *ast.File
+	fset *token.FileSet
+
+	docs map[declKey]*ast.CommentGroup // Doc comments of all declarations in the file, see indexDocComments().
+}
+
+// Declaration's name and line. Export data don't record columns.
+type declKey struct {
+	name string
+	line int
+}
+
+// Returns the syntax tree of the given Go source file.
+//
+// Packages loaded from source (the schema package) share the same file set. Packages
+// imported via export data don't have any syntax loaded, but their objects still carry
+// file:line positions, so we parse their source files on demand and cache them.
+func (p *Parser) getSyntaxFile(filename string) *syntaxFile {
+	if p.syntaxFiles == nil {
+		p.syntaxFiles = map[string]*syntaxFile{}
+		packages.Visit([]*packages.Package{p.Pkg}, nil, func(pkg *packages.Package) {
+			for _, file := range pkg.Syntax {
+				p.syntaxFiles[pkg.Fset.Position(file.Package).Filename] = &syntaxFile{file: file, fset: pkg.Fset}
+			}
+		})
+	}
+
+	if f, ok := p.syntaxFiles[filename]; ok {
+		return f
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		file = nil // Cache the miss, so we don't try to parse the file again.
+	}
+	p.syntaxFiles[filename] = &syntaxFile{file: file, fset: fset}
+
+	return p.syntaxFiles[filename]
+}
+
+// Returns doc comment lines of the given type, struct field or interface method.
+func (p *Parser) getDocComments(obj types.Object) []string {
+	doc := p.getDocCommentGroup(obj)
+	if doc == nil {
+		return nil
+	}
+
+	text := strings.TrimSpace(doc.Text()) // Text() drops the "//" markers and //go: directives.
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(text, "\n")
+}
+
+// Reports whether the doc comment has a "Deprecated:" paragraph, ie. "Deprecated: Use X instead."
+func isDeprecated(comments []string) bool {
+	for _, line := range comments {
+		if strings.HasPrefix(line, "Deprecated:") {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the argument of the directive in the doc comment of the given object, ie. "Pet"
+// for //gospeak:name Pet, and reports whether the directive is present.
+func (p *Parser) getDirective(obj types.Object, directive string) (string, bool) {
+	return directiveArg(p.getDocCommentGroup(obj), directive)
+}
+
+// Returns the argument of the directive in the comment group, see getDirective().
+func directiveArg(doc *ast.CommentGroup, directive string) (string, bool) {
+	if doc == nil {
+		return "", false
+	}
+
+	for _, comment := range doc.List {
+		text := strings.TrimSpace(comment.Text)
+		if text == directive {
+			return "", true
+		}
+		if arg, ok := strings.CutPrefix(text, directive+" "); ok {
+			return strings.TrimSpace(arg), true
+		}
+	}
+	return "", false
+}
+
+// Finds the declaration of the given object in its source file and returns its doc comment.
+func (p *Parser) getDocCommentGroup(obj types.Object) *ast.CommentGroup {
+	if obj == nil || !obj.Pos().IsValid() {
+		return nil
+	}
+
+	position := p.Pkg.Fset.Position(obj.Pos())
+	f := p.getSyntaxFile(position.Filename)
+	if f.file == nil {
+		return nil
+	}
+
+	if f.docs == nil {
+		f.indexDocComments()
+	}
+
+	return f.docs[declKey{name: obj.Name(), line: position.Line}]
+}
+
+// Walks the file once and indexes doc comments of all type declarations,
+// constants, struct fields and interface methods by their name and line.
+func (f *syntaxFile) indexDocComments() {
+	f.docs = map[declKey]*ast.CommentGroup{}
+
+	add := func(ident *ast.Ident, doc *ast.CommentGroup) {
+		if doc != nil {
+			f.docs[declKey{name: ident.Name, line: f.fset.Position(ident.Pos()).Line}] = doc
+		}
+	}
+
+	ast.Inspect(f.file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.GenDecl:
+			switch n.Tok {
+			case token.TYPE:
+				for _, spec := range n.Specs {
+					if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+						doc := typeSpec.Doc
+						if doc == nil && len(n.Specs) == 1 {
+							doc = n.Doc // type Name struct{}
+						}
+						add(typeSpec.Name, doc)
+					}
+				}
+
+			case token.CONST: // Enum values.
+				for _, spec := range n.Specs {
+					if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+						doc := valueSpec.Doc
+						if doc == nil && len(n.Specs) == 1 {
+							doc = n.Doc // const Name = 1
+						}
+						for _, name := range valueSpec.Names {
+							add(name, doc)
+						}
+					}
+				}
+				return false
+
+			default:
+				return n.Tok == token.VAR // Anonymous structs can be declared in vars.
+			}
+
+		case *ast.Field:
+			for _, name := range n.Names {
+				add(name, n.Doc)
+			}
+
+		case *ast.FuncDecl:
+			return false // Don't look inside of function bodies.
+		}
+
+		return true
+	})
+}
+
+// Finds the type declaration of the given object in its source file.
+func (p *Parser) getTypeSpec(obj *types.TypeName) (*ast.GenDecl, *ast.TypeSpec) {
+	if obj == nil || !obj.Pos().IsValid() {
+		return nil, nil
+	}
+
+	position := p.Pkg.Fset.Position(obj.Pos())
+	f := p.getSyntaxFile(position.Filename)
+	if f.file == nil {
+		return nil, nil
+	}
+
+	for _, decl := range f.file.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.TYPE {
+			for _, spec := range genDecl.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.Name == obj.Name() && f.fset.Position(typeSpec.Name.Pos()).Line == position.Line {
+					return genDecl, typeSpec
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/golang-cz/gospeak/internal/parser"
+)
+
+// Generates schema package with n struct types, n enums and an interface
+// with n methods, each type referencing the next one.
+func genLargeAPI(n int) string {
+	var b strings.Builder
+
+	b.WriteString(`package test
+
+	import (
+		"context"
+		"time"
+
+		"github.com/golang-cz/gospeak/enum"
+	)
+
+	var _ time.Time
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+	`)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "\t// Get%[1]v returns Type%[1]v.\n\tGet%[1]v(ctx context.Context, id int64) (t *Type%[1]v, err error)\n", i)
+	}
+	b.WriteString("}\n")
+
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `
+	// Type%[1]v is documented.
+	type Type%[1]v struct {
+		// ID is documented.
+		ID        int64             `+"`json:\"id,string\"`"+`
+		Name      string            `+"`json:\"name\"`"+`
+		Tags      []string          `+"`json:\"tags\"`"+`
+		Meta      map[string]string `+"`json:\"meta,omitempty\"`"+`
+		Status    Status%[1]v
+		CreatedAt time.Time
+		Next      *Type%[2]v
+	}
+
+	// a
+	// b
+	// c
+	type Status%[1]v enum.Int
+	`, i, (i+1)%n)
+	}
+
+	return b.String()
+}
+
+func BenchmarkParseInterface(b *testing.B) {
+	for _, n := range []int{10, 100, 500} {
+		b.Run(fmt.Sprintf("types=%v", n), func(b *testing.B) {
+			p, err := testParser(genLargeAPI(n))
+			if err != nil {
+				b.Fatal(err)
+			}
+			iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p := parser.New(p.Pkg)
+				if err := p.CollectEnums(); err != nil {
+					b.Fatal(err)
+				}
+				if err := p.ParseInterfaceMethods(iface, "TestAPI"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
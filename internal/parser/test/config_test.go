@@ -0,0 +1,141 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-cz/gospeak"
+	"github.com/google/go-cmp/cmp"
+	"github.com/webrpc/webrpc/schema"
+)
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	// The //go:webrpc comments are ignored, the targets are defined by gospeak.yaml.
+	dir := writeTestFiles(t, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.20\n",
+		"gospeak.yaml": `flags: [--const-enums]
+type-mappings:
+  example.com/app/money.Money: string
+schemas:
+  - package: ./api
+    interface: PetStore
+    targets:
+      - golang -server -pkg=api -out=./api/server.gen.go
+      - typescript -client -out=web/{service}.gen.ts
+  - package: ./api
+    interface: Admin
+    targets:
+      - json -out=/dev/null
+`,
+		"api/api.go": `package api
+
+import (
+	"context"
+
+	"example.com/app/money"
+)
+
+//go:webrpc json -out=./ignored.gen.json
+type PetStore interface {
+	GetPrice(ctx context.Context, id int64) (price money.Money, err error)
+}
+
+type Admin interface {
+	Ping(ctx context.Context) error
+}
+`,
+		"money/money.go": `package money
+
+type Money struct {
+	Cents int64
+}
+`,
+	})
+
+	config, err := gospeak.LoadConfig(filepath.Join(dir, "gospeak.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"--const-enums"}; !cmp.Equal(want, config.Flags) {
+		t.Errorf("unexpected flags\n%s", coloredDiff(want, config.Flags))
+	}
+
+	targets, err := gospeak.ParseConfig(config, gospeak.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type target struct {
+		Generator     string
+		InterfaceName string
+		OutFile       string
+		Opts          map[string]interface{}
+	}
+	var got []target
+	for _, tgt := range targets {
+		got = append(got, target{tgt.Generator, tgt.InterfaceName, tgt.OutFile, tgt.Opts})
+	}
+	want := []target{
+		{"golang", "PetStore", filepath.Join(dir, "api", "server.gen.go"), map[string]interface{}{"server": "", "pkg": "api"}},
+		{"typescript", "PetStore", filepath.Join(dir, "web", "PetStore.gen.ts"), map[string]interface{}{"client": ""}},
+		{"json", "Admin", "/dev/null", map[string]interface{}{}},
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected targets\n%s", coloredDiff(want, got))
+	}
+
+	if targets[0].Schema != targets[1].Schema {
+		t.Errorf("expected PetStore to be parsed once for both targets")
+	}
+
+	price := targets[0].Schema.Services[0].Methods[0].Outputs[0]
+	if price.Type.Type != schema.T_String {
+		t.Errorf("expected money.Money mapped to string by type-mappings, got %v", price.Type)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		config string
+		err    string
+	}{
+		{
+			config: "flags: [--strict]\n",
+			err:    "no schemas defined",
+		},
+		{
+			config: "schemas:\n  - package: ./api\n    interface: API\n    target: [json -out=api.json]\n",
+			err:    "field target not found",
+		},
+		{
+			config: "schemas:\n  - interface: API\n    targets: [json -out=api.json]\n",
+			err:    "schemas[0]: package is required",
+		},
+		{
+			config: "schemas:\n  - package: ./api\n    interface: api.API\n    targets: [json -out=api.json]\n",
+			err:    `schemas[0]: invalid interface name "api.API"`,
+		},
+		{
+			config: "schemas:\n  - package: ./api\n    interface: API\n",
+			err:    "schemas[0]: API has no targets",
+		},
+		{
+			config: "schemas:\n  - package: ./api\n    interface: API\n    targets: [json -out=api.json]\n  - package: api\n    interface: API\n    targets: [debug -out=api.txt]\n",
+			err:    "schemas[1]: API of api is defined twice",
+		},
+	}
+
+	for _, tc := range tt {
+		dir := writeTestFiles(t, map[string]string{"gospeak.yaml": tc.config})
+
+		_, err := gospeak.LoadConfig(filepath.Join(dir, "gospeak.yaml"))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s\nexpected error %q, got %v", tc.config, tc.err, err)
+		}
+	}
+}
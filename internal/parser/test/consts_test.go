@@ -0,0 +1,141 @@
+package test
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/webrpc/webrpc/schema"
+)
+
+func TestConsts(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		ListPets(ctx context.Context, limit int) (names []string, err error)
+	}
+
+	// Limits of the list methods.
+	//
+	//gospeak:const Limits
+	const (
+		// Max number of items per page.
+		MaxPageSize     = 100
+		DefaultPageSize = 20
+		minPageSize     = 1
+
+		MaxPrice float64 = 99.5
+		Currency         = "EUR"
+		Beta             = true
+	)
+
+	//gospeak:const Versions
+	const APIVersion = "v2"
+
+	// Not exported into the schema.
+	const Unrelated = 1
+	`
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CollectConsts(); err != nil {
+		t.Fatal(err)
+	}
+
+	iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+	if err := p.ParseInterfaceMethods(iface, "TestAPI"); err != nil {
+		t.Fatal(err)
+	}
+	p.PruneTypes() // Constants are kept, even though no method refers to them.
+
+	constField := func(name string, typ schema.CoreType, value string, comments ...string) *schema.TypeField {
+		return &schema.TypeField{
+			Name:     name,
+			Type:     &schema.VarType{Expr: typ.String(), Type: typ},
+			Comments: comments,
+			TypeExtra: schema.TypeExtra{
+				Meta: []schema.TypeFieldMeta{{"const": value}},
+			},
+		}
+	}
+
+	want := []*schema.Type{
+		{
+			Kind:     "struct",
+			Name:     "Limits",
+			Comments: []string{"Limits of the list methods."},
+			Fields: []*schema.TypeField{
+				constField("MaxPageSize", schema.T_Int, "100", "Max number of items per page."),
+				constField("DefaultPageSize", schema.T_Int, "20"),
+				constField("MaxPrice", schema.T_Float64, "99.5"),
+				constField("Currency", schema.T_String, `"EUR"`),
+				constField("Beta", schema.T_Bool, "true"),
+			},
+			TypeExtra: schema.TypeExtra{
+				Meta: []schema.TypeFieldMeta{{"constants": "true"}},
+			},
+		},
+		{
+			Kind: "struct",
+			Name: "Versions",
+			Fields: []*schema.TypeField{
+				constField("APIVersion", schema.T_String, `"v2"`),
+			},
+			TypeExtra: schema.TypeExtra{
+				Meta: []schema.TypeFieldMeta{{"constants": "true"}},
+			},
+		},
+	}
+	if !cmp.Equal(want, p.Schema.Types) {
+		t.Errorf("%s", coloredDiff(want, p.Schema.Types))
+	}
+}
+
+func TestConstsErrors(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in  string
+		err string
+	}{
+		{
+			in:  "//gospeak:const\nconst MaxPageSize = 100",
+			err: `invalid //gospeak:const directive "", expected type name`,
+		},
+		{
+			in:  "//gospeak:const Pet\nconst MaxPageSize = 100\n\ntype Pet struct{}",
+			err: "type name Pet given by //gospeak:const directive is already taken",
+		},
+		{
+			in:  "//gospeak:const Limits\nconst MaxPageSize = 100\n\n//gospeak:const Limits\nconst MinPageSize = 1",
+			err: "type name Limits given by //gospeak:const directive is already taken",
+		},
+		{
+			in:  "//gospeak:const Limits\nconst Timeout = 5 * time.Second",
+			err: "Timeout: unsupported constant type time.Duration, expected bool, number or string",
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf("package test\n\nimport \"time\"\n\nvar _ = time.Now\n\n%s\n", tc.in)
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = p.CollectConsts()
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s\nexpected error %q, got %v", tc.in, tc.err, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -12,9 +13,11 @@ func TestStructFieldEnum(t *testing.T) {
 	t.Parallel()
 
 	tt := []struct {
-		in  string
-		t   schema.CoreType
-		out []*schema.TypeField
+		in       string
+		t        schema.CoreType
+		comments []string
+		meta     []schema.TypeFieldMeta
+		out      []*schema.TypeField
 	}{
 		{
 			in: `
@@ -49,6 +52,88 @@ func TestStructFieldEnum(t *testing.T) {
 				&schema.TypeField{Name: "new", TypeExtra: schema.TypeExtra{Value: "3"}},
 			},
 		},
+		{
+			in: `
+				// Enum is documented.
+				// It spans two lines.
+				//
+				// approved
+				// pending = 5
+				type Enum enum.Int
+			`,
+			t:        schema.T_Int,
+			comments: []string{"Enum is documented.", "It spans two lines."},
+			out: []*schema.TypeField{
+				&schema.TypeField{Name: "approved", TypeExtra: schema.TypeExtra{Value: "0"}},
+				&schema.TypeField{Name: "pending", TypeExtra: schema.TypeExtra{Value: "5"}},
+			},
+		},
+		{
+			in: `
+				// approved = "APPROVED"
+				// pending
+				// closed = 5
+				type Enum enum.Int
+			`,
+			t: schema.T_Int,
+			out: []*schema.TypeField{
+				&schema.TypeField{Name: "approved", TypeExtra: schema.TypeExtra{Value: "0", Meta: []schema.TypeFieldMeta{{"json": "APPROVED"}}}},
+				&schema.TypeField{Name: "pending", TypeExtra: schema.TypeExtra{Value: "1"}},
+				&schema.TypeField{Name: "closed", TypeExtra: schema.TypeExtra{Value: "5"}},
+			},
+		},
+		{
+			in: `
+				// a = 1
+				// b = 2
+				// c = "C"
+				// d
+				// e = 10
+				// f = "F"
+				type Enum enum.Int
+			`,
+			t: schema.T_Int,
+			out: []*schema.TypeField{
+				&schema.TypeField{Name: "a", TypeExtra: schema.TypeExtra{Value: "1"}},
+				&schema.TypeField{Name: "b", TypeExtra: schema.TypeExtra{Value: "2"}},
+				&schema.TypeField{Name: "c", TypeExtra: schema.TypeExtra{Value: "3", Meta: []schema.TypeFieldMeta{{"json": "C"}}}},
+				&schema.TypeField{Name: "d", TypeExtra: schema.TypeExtra{Value: "4"}},
+				&schema.TypeField{Name: "e", TypeExtra: schema.TypeExtra{Value: "10"}},
+				&schema.TypeField{Name: "f", TypeExtra: schema.TypeExtra{Value: "11", Meta: []schema.TypeFieldMeta{{"json": "F"}}}},
+			},
+		},
+		{
+			in: `
+				// Enum is sent as integer.
+				//
+				// approved
+				// pending
+				//
+				//go:webrpc json=int
+				type Enum enum.Int
+			`,
+			t:        schema.T_Int,
+			comments: []string{"Enum is sent as integer."},
+			meta:     []schema.TypeFieldMeta{{"json": "int"}},
+			out: []*schema.TypeField{
+				&schema.TypeField{Name: "approved", TypeExtra: schema.TypeExtra{Value: "0"}},
+				&schema.TypeField{Name: "pending", TypeExtra: schema.TypeExtra{Value: "1"}},
+			},
+		},
+		{
+			in: `
+				// approved
+				// closed (deprecated)
+				// new = "NEW" (deprecated)
+				type Enum enum.Int
+			`,
+			t: schema.T_Int,
+			out: []*schema.TypeField{
+				&schema.TypeField{Name: "approved", TypeExtra: schema.TypeExtra{Value: "0"}},
+				&schema.TypeField{Name: "closed", TypeExtra: schema.TypeExtra{Value: "1", Meta: []schema.TypeFieldMeta{{"deprecated": "true"}}}},
+				&schema.TypeField{Name: "new", TypeExtra: schema.TypeExtra{Value: "2", Meta: []schema.TypeFieldMeta{{"json": "NEW"}, {"deprecated": "true"}}}},
+			},
+		},
 		{
 			// TODO: Can we also support "cs-CZ"?
 			in: `
@@ -183,8 +268,10 @@ func TestStructFieldEnum(t *testing.T) {
 				Expr: tc.t.String(),
 				Type: tc.t,
 			},
-			Fields: tc.out,
+			Fields:   tc.out,
+			Comments: tc.comments,
 		}
+		want.Meta = tc.meta
 
 		var got *schema.Type
 		for _, schemaType := range p.Schema.Types {
@@ -199,3 +286,332 @@ func TestStructFieldEnum(t *testing.T) {
 
 	}
 }
+
+func TestConstEnums(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	// Status of the approval process.
+	type Status int
+
+	const (
+		// StatusApproved is final.
+		StatusApproved Status = iota
+		StatusPending
+		// Deprecated: Use StatusPending instead.
+		StatusClosed
+
+		statusUnknown Status = 99
+	)
+
+	// Level has its own String() method, which would clash with the generated code.
+	type Level uint8
+
+	const (
+		LevelLow Level = iota + 1
+		LevelHigh
+	)
+
+	func (l Level) String() string { return "" }
+
+	type TestStruct struct {
+		Status Status
+		Level  Level
+	}
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface{
+		Test(ctx context.Context) (tst *TestStruct, err error)
+	}
+
+	var _ = statusUnknown
+	`
+
+	for _, constEnums := range []bool{false, true} {
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ConstEnums = constEnums
+
+		if err := p.CollectEnums(); err != nil {
+			t.Fatalf("collecting enums: %v", err)
+		}
+		if err := parseStruct(p, "TestStruct"); err != nil {
+			t.Fatal(err)
+		}
+
+		testStruct := p.Schema.GetTypeByName("TestStruct")
+		var fields []string
+		for _, field := range testStruct.Fields {
+			fields = append(fields, fmt.Sprintf("%v %v", field.Name, field.Type))
+		}
+
+		if !constEnums {
+			if want := []string{"Status int", "Level uint8"}; !cmp.Equal(want, fields) {
+				t.Errorf("%s", coloredDiff(want, fields))
+			}
+			if p.Schema.GetTypeByName("Status") != nil {
+				t.Errorf("unexpected Status enum")
+			}
+			continue
+		}
+
+		if want := []string{"Status Status", "Level uint8"}; !cmp.Equal(want, fields) {
+			t.Errorf("%s", coloredDiff(want, fields))
+		}
+
+		want := &schema.Type{
+			Kind: schema.TypeKind_Enum,
+			Name: "Status",
+			Type: &schema.VarType{
+				Expr: "int",
+				Type: schema.T_Int,
+			},
+			Fields: []*schema.TypeField{
+				&schema.TypeField{Name: "Approved", Comments: []string{"StatusApproved is final."}, TypeExtra: schema.TypeExtra{Value: "0"}},
+				&schema.TypeField{Name: "Pending", TypeExtra: schema.TypeExtra{Value: "1"}},
+				&schema.TypeField{Name: "Closed", Comments: []string{"Deprecated: Use StatusPending instead."}, TypeExtra: schema.TypeExtra{Value: "2", Meta: []schema.TypeFieldMeta{{"deprecated": "true"}}}},
+			},
+			Comments: []string{"Status of the approval process."},
+		}
+		if got := p.Schema.GetTypeByName("Status"); !cmp.Equal(want, got) {
+			t.Errorf("%s", coloredDiff(want, got))
+		}
+		if p.Schema.GetTypeByName("Level") != nil {
+			t.Errorf("unexpected Level enum")
+		}
+	}
+}
+
+func TestConstStringEnums(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in  string
+		out []*schema.TypeField
+		err string
+	}{
+		{
+			in: `const (
+				KindCat Kind = "cat"
+				KindDog Kind = "dog"
+			)`,
+			out: []*schema.TypeField{
+				&schema.TypeField{Name: "cat", TypeExtra: schema.TypeExtra{Value: "cat"}},
+				&schema.TypeField{Name: "dog", TypeExtra: schema.TypeExtra{Value: "dog"}},
+			},
+		},
+		{
+			in:  `const KindGuineaPig Kind = "guinea-pig"`,
+			err: `proto.go:9:10: KindGuineaPig: string enum value "guinea-pig" must be a valid identifier`,
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+			type Kind string
+
+			type TestStruct struct {
+				Kind Kind
+			}
+
+			%s
+			`, tc.in)
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ConstEnums = true
+
+		err = p.CollectEnums()
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s\nexpected error %q, got: %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("collecting enums: %v", err)
+		}
+
+		want := &schema.Type{
+			Kind: schema.TypeKind_Enum,
+			Name: "Kind",
+			Type: &schema.VarType{
+				Expr: "string",
+				Type: schema.T_String,
+			},
+			Fields: tc.out,
+		}
+		if got := p.Schema.GetTypeByName("Kind"); !cmp.Equal(want, got) {
+			t.Errorf("%s\n%s", tc.in, coloredDiff(want, got))
+		}
+	}
+}
+
+func TestExternalEnums(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "github.com/golang-cz/gospeak/internal/parser/test/external"
+
+	type TestStruct struct {
+		Status external.Status
+		Kind   external.Kind
+	}
+	`
+
+	for _, constEnums := range []bool{false, true} {
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ConstEnums = constEnums
+
+		if err := p.CollectEnums(); err != nil {
+			t.Fatalf("collecting enums: %v", err)
+		}
+		if err := parseStruct(p, "TestStruct"); err != nil {
+			t.Fatal(err)
+		}
+
+		testStruct := p.Schema.GetTypeByName("TestStruct")
+		var fields []string
+		for _, field := range testStruct.Fields {
+			fields = append(fields, fmt.Sprintf("%v %v", field.Name, field.Type))
+		}
+
+		want := []string{"Status externalStatus", "Kind string"}
+		if constEnums {
+			want = []string{"Status externalStatus", "Kind externalKind"}
+		}
+		if !cmp.Equal(want, fields) {
+			t.Errorf("%s", coloredDiff(want, fields))
+		}
+
+		wantStatus := &schema.Type{
+			Kind: schema.TypeKind_Enum,
+			Name: "externalStatus",
+			Type: &schema.VarType{
+				Expr: "int",
+				Type: schema.T_Int,
+			},
+			Fields: []*schema.TypeField{
+				&schema.TypeField{Name: "available", TypeExtra: schema.TypeExtra{Value: "0"}},
+				&schema.TypeField{Name: "sold", TypeExtra: schema.TypeExtra{Value: "1"}},
+			},
+		}
+		if got := p.Schema.GetTypeByName("externalStatus"); !cmp.Equal(wantStatus, got) {
+			t.Errorf("%s", coloredDiff(wantStatus, got))
+		}
+
+		if !constEnums {
+			continue
+		}
+
+		wantKind := &schema.Type{
+			Kind: schema.TypeKind_Enum,
+			Name: "externalKind",
+			Type: &schema.VarType{
+				Expr: "string",
+				Type: schema.T_String,
+			},
+			Fields: []*schema.TypeField{
+				&schema.TypeField{Name: "cat", TypeExtra: schema.TypeExtra{Value: "cat"}},
+				&schema.TypeField{Name: "dog", TypeExtra: schema.TypeExtra{Value: "dog"}},
+			},
+		}
+		if got := p.Schema.GetTypeByName("externalKind"); !cmp.Equal(wantKind, got) {
+			t.Errorf("%s", coloredDiff(wantKind, got))
+		}
+	}
+}
+
+func TestEnumDirectiveErrors(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in  string
+		err string
+	}{
+		{
+			in: `
+				// approved
+				//go:webrpc json=float
+				type Enum enum.Int
+			`,
+			err: `proto.go:8:10: Enum enum: invalid "json=float" directive, expected json=int or json=string`,
+		},
+		{
+			in: `
+				// approved
+				//go:webrpc omitempty
+				type Enum enum.Int
+			`,
+			err: `proto.go:8:10: Enum enum: unknown "omitempty" directive`,
+		},
+		{
+			in: `
+				//go:webrpc json=int
+				type Enum string
+
+				const EnumApproved Enum = "approved"
+			`,
+			err: `proto.go:7:10: Enum enum: json=int directive requires an integer enum type, got string`,
+		},
+		{
+			in: `
+				// a = 1
+				// b
+				// c = 2
+				type Enum enum.Int
+			`,
+			err: `proto.go:9:10: Enum enum: c has value 2, same as b`,
+		},
+		{
+			in: `
+				// a = "A"
+				// b = "A"
+				type Enum enum.Int
+			`,
+			err: `proto.go:8:10: Enum enum: b is sent as "A", same as a`,
+		},
+		{
+			in: `
+				// A
+				// b = "A"
+				type Enum enum.Int
+			`,
+			err: `proto.go:8:10: Enum enum: b is sent as "A", same as A`,
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+			import "github.com/golang-cz/gospeak/enum"
+
+			%s
+
+			var _ enum.Int
+			`, tc.in)
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ConstEnums = true
+
+		err = p.CollectEnums()
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s\nexpected error %q, got: %v", tc.in, tc.err, err)
+		}
+	}
+}
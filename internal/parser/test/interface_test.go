@@ -0,0 +1,1693 @@
+package test
+
+import (
+	"fmt"
+	"go/build"
+	"go/types"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-cz/gospeak"
+	"github.com/golang-cz/gospeak/internal/parser"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInterfaceFromOtherPackage(t *testing.T) {
+	t.Parallel()
+
+	type method struct {
+		name     string
+		comments []string
+	}
+
+	tt := []struct {
+		in  string
+		out []method
+	}{
+		{
+			in: `type TestAPI = external.ReadAPI`,
+			out: []method{
+				{name: "GetItem", comments: []string{"GetItem returns a single item."}},
+			},
+		},
+		{
+			in: `type TestAPI external.ReadAPI`,
+			out: []method{
+				{name: "GetItem", comments: []string{"GetItem returns a single item."}},
+			},
+		},
+		{
+			in: `type TestAPI interface {
+				external.ReadAPI
+
+				// Ping checks the service health.
+				Ping(ctx context.Context) error
+			}`,
+			out: []method{
+				{name: "GetItem", comments: []string{"GetItem returns a single item."}},
+				{name: "Ping", comments: []string{"Ping checks the service health."}},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+			import (
+				"context"
+
+				"github.com/golang-cz/gospeak/internal/parser/test/external"
+			)
+
+			//go:webrpc json -out=/dev/null
+			%s
+
+			var _ context.Context
+			`, tc.in)
+
+		schema := parseTestAPI(t, srcCode)
+
+		var got []method
+		for _, m := range schema.Services[0].Methods {
+			got = append(got, method{name: m.Name, comments: m.Comments})
+		}
+
+		if !cmp.Equal(tc.out, got, cmp.AllowUnexported(method{})) {
+			t.Errorf("%s\n%s", tc.in, coloredDiff(tc.out, got, cmp.AllowUnexported(method{})))
+		}
+	}
+}
+
+func TestInterfaceDocComments(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	// TestAPI is documented.
+	// It spans two lines.
+	//
+	//go:webrpc json -out=/dev/null
+	//go:webrpc debug -out=/dev/null
+	type TestAPI interface {
+		// Ping checks the service health.
+		Ping(ctx context.Context) error
+
+		Version(ctx context.Context) (version string, err error)
+	}
+	`
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+	if err := p.ParseInterfaceMethods(iface, "TestAPI"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string][]string{}
+	service := p.Schema.Services[0]
+	got["TestAPI"] = service.Comments
+	for _, method := range service.Methods {
+		got[method.Name+"()"] = method.Comments
+	}
+
+	want := map[string][]string{
+		"TestAPI":   {"TestAPI is documented.", "It spans two lines."},
+		"Ping()":    {"Ping checks the service health."},
+		"Version()": nil,
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+}
+
+func TestInterfaceUnexportedTypes(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in  string
+		err string
+	}{
+		{
+			in: `Get(ctx context.Context) (p *pet, err error)
+			}
+
+			type pet struct {
+				Name string`,
+			err: "unexported type pet can't be used in the API: TestAPI.Get() => p",
+		},
+		{
+			in: `Get(ctx context.Context) (p *Pet, err error)
+			}
+
+			type Pet struct {
+				Owner *owner
+			}
+
+			type owner struct {
+				Name string`,
+			err: "unexported type owner can't be used in the API: TestAPI.Get() => p => Pet.Owner",
+		},
+		{
+			in: `Get(ctx context.Context, req *Request) error
+			}
+
+			type Request struct {
+				Secret Secret
+			}
+
+			type Secret struct {
+				value string`,
+			err: "type Secret has no exported fields, it would always be serialized as {}: TestAPI.Get() => req => Request.Secret",
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+			import "context"
+
+			//go:webrpc json -out=/dev/null
+			type TestAPI interface {
+				%s
+			}
+			`, tc.in)
+
+		_, err := testParseAPI(srcCode)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s\nexpected error %q, got: %v", tc.in, tc.err, err)
+		}
+	}
+}
+
+func TestInterfaceUniqueTypeNames(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Get(ctx context.Context) (a *A, b *B, err error)
+	}
+
+	type A struct {
+		Field struct {
+			X int
+		}
+	}
+
+	type B struct {
+		Field struct {
+			Y int
+		}
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	var got []string
+	for _, typ := range schema.Types {
+		got = append(got, typ.Name)
+	}
+
+	want := []string{"A", "AnonymousField", "AnonymousField2", "B"}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+}
+
+func TestInterfaceTypesOrder(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/enum"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		GetZoo(ctx context.Context) (zoo *Zoo, err error)
+		GetAnimal(ctx context.Context) (animal *Animal, err error)
+	}
+
+	type Zoo struct {
+		Name    string
+		Keeper  *Keeper
+		Animals []*Animal
+	}
+
+	type Keeper struct {
+		Name string
+	}
+
+	type Animal struct {
+		Name    string
+		Kind    Kind
+		Habitat Habitat
+	}
+
+	// habitat
+	type Habitat enum.Int
+
+	// animal
+	type Kind enum.Int
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	var got []string
+	for _, typ := range schema.Types {
+		got = append(got, fmt.Sprintf("%v %v", typ.Kind, typ.Name))
+	}
+
+	want := []string{"enum Habitat", "enum Kind", "struct Animal", "struct Keeper", "struct Zoo"}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+
+	// Fields keep their declaration order.
+	var fields []string
+	for _, field := range schema.Types[4].Fields {
+		fields = append(fields, field.Name)
+	}
+	if want := []string{"Name", "Keeper", "Animals"}; !cmp.Equal(want, fields) {
+		t.Errorf("%s", coloredDiff(want, fields))
+	}
+}
+
+func TestInterfaceReproducibleSchema(t *testing.T) {
+	t.Parallel()
+
+	// Same API with the declarations shuffled.
+	srcCodes := []string{`package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/enum"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		//gospeak:name ListAnimals
+		GetAnimals(ctx context.Context, filter struct{ Kind Kind }) (animals []*Animal, err error)
+		GetZoo(ctx context.Context) (zoo *Zoo, err error)
+	}
+
+	type Zoo struct {
+		Name    string
+		Animals []*Animal
+	}
+
+	type Animal struct {
+		Name string
+		Kind Kind
+	}
+
+	// cat
+	// dog
+	type Kind enum.Int
+	`, `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/enum"
+	)
+
+	// cat
+	// dog
+	type Kind enum.Int
+
+	type Animal struct {
+		Name string
+		Kind Kind
+	}
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		GetZoo(ctx context.Context) (zoo *Zoo, err error)
+		//gospeak:name ListAnimals
+		GetAnimals(ctx context.Context, filter struct{ Kind Kind }) (animals []*Animal, err error)
+	}
+
+	type Zoo struct {
+		Name    string
+		Animals []*Animal
+	}
+	`}
+
+	var schemas []string
+	for _, srcCode := range srcCodes {
+		schema := parseTestAPI(t, srcCode)
+
+		var methods []string
+		for _, m := range schema.Services[0].Methods {
+			methods = append(methods, m.Name)
+		}
+		if want := []string{"GetZoo", "ListAnimals"}; !cmp.Equal(want, methods) {
+			t.Errorf("%s", coloredDiff(want, methods))
+		}
+
+		out, err := schema.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		schemas = append(schemas, out)
+	}
+
+	if schemas[0] != schemas[1] {
+		t.Errorf("schema depends on the declaration order:\n%s", coloredDiff(schemas[0], schemas[1]))
+	}
+}
+
+func TestInterfaceWithTypeErrors(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		GetItem(ctx context.Context) (item *Item, err error)
+	}
+
+	//go:webrpc json -out=/dev/null
+	type BrokenAPI interface {
+		GetBroken(ctx context.Context) (broken *Broken, err error)
+	}
+
+	type Item struct {
+		ID int64
+	}
+
+	type Broken struct {
+		ID     int64
+		Reason Undefined
+	}
+	`
+
+	p, err := testParserWithTypeErrors(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, wantErr := range map[string]string{
+		"TestAPI":   "",
+		"BrokenAPI": "invalid type (see type-checking errors above): BrokenAPI.GetBroken() => broken => Broken.Reason",
+	} {
+		p := parser.New(p.Pkg)
+		iface := p.Pkg.Types.Scope().Lookup(name).Type().Underlying().(*types.Interface)
+
+		err := p.ParseInterfaceMethods(iface, name)
+		if wantErr == "" {
+			if err != nil {
+				t.Errorf("%v: unexpected error: %v", name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%v: expected error %q, got %v", name, wantErr, err)
+		}
+	}
+}
+
+func TestInterfaceOutFilePlaceholders(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in  string
+		out string
+		err string
+	}{
+		{in: `json -out=./api.gen.json`, out: "api.gen.json"},
+		{in: `json -out=../{package}/{service}.gen.json`, out: "../test/TestAPI.gen.json"},
+		{in: `golang@v0.11.0 -out=./client/{version}/client.gen.go`, out: "client/v0.11.0/client.gen.go"},
+		{in: `golang -out=./client/{version}/client.gen.go`, err: `generator is not versioned`},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+			//go:webrpc %s
+			type TestAPI interface {}
+			`, tc.in)
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		targets, err := gospeak.CollectInterfaces(p.Pkg)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.in, err)
+		}
+
+		if want := filepath.FromSlash(tc.out); targets[0].OutFile != want {
+			t.Errorf("%s: expected %q, got %q", tc.in, want, targets[0].OutFile)
+		}
+	}
+}
+
+func TestInterfaceProvenance(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/enum"
+		"github.com/golang-cz/gospeak/internal/parser/test/external"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		external.ReadAPI
+
+		GetPet(ctx context.Context) (pet *Pet, err error)
+	}
+
+	type Pet struct {
+		Name   string
+		Status Status
+	}
+
+	// available
+	// sold
+	type Status enum.Int
+	`
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Provenance = true
+
+	if err := p.CollectEnums(); err != nil {
+		t.Fatal(err)
+	}
+	iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+	if err := p.ParseInterfaceMethods(iface, "TestAPI"); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for _, typ := range p.Schema.Types {
+		for _, meta := range typ.Meta {
+			if source, ok := meta["go.source"]; ok {
+				got[typ.Name] = fmt.Sprint(source)
+			}
+		}
+	}
+	for _, method := range p.Schema.Services[0].Methods {
+		if source, ok := method.Annotations["go.source"]; ok {
+			got[method.Name+"()"] = source.Value
+		}
+	}
+
+	want := map[string]string{
+		"GetItem()":    "external/external.go:13",
+		"GetPet()":     "proto.go:14",
+		"Pet":          "proto.go:17",
+		"Status":       "proto.go:24",
+		"externalItem": "external/external.go:16",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+}
+
+func TestInterfaceGenericTypes(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/internal/parser/test/external"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		ListPets(ctx context.Context) (page *Page[Pet], err error)
+		ListPetPtrs(ctx context.Context) (page *Page[*Pet], err error)
+		ListItems(ctx context.Context) (page *Page[external.Item], err error)
+		GetPet(ctx context.Context) (page *Page[Pet], res Result[Pet, string], err error)
+	}
+
+	type Pet struct {
+		Name string
+	}
+
+	type Page[T any] struct {
+		Items []T
+		Next  *Page[T]
+	}
+
+	type Result[T any, E any] struct {
+		Value T
+		Err   E
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	got := map[string]string{}
+	for _, typ := range schema.Types {
+		var fields []string
+		for _, field := range typ.Fields {
+			fields = append(fields, fmt.Sprintf("%v %v", field.Name, field.Type))
+		}
+		got[typ.Name] = strings.Join(fields, ", ")
+	}
+	for _, method := range schema.Services[0].Methods {
+		var outputs []string
+		for _, output := range method.Outputs {
+			outputs = append(outputs, fmt.Sprintf("%v %v", output.Name, output.Type))
+		}
+		got[method.Name+"()"] = strings.Join(outputs, ", ")
+	}
+
+	want := map[string]string{
+		"GetPet()":         "page PagePet, res ResultPetString",
+		"ListItems()":      "page PageExternalItem",
+		"ListPetPtrs()":    "page PagePetPtr",
+		"ListPets()":       "page PagePet",
+		"PageExternalItem": "Items []externalItem, Next PageExternalItem",
+		"PagePet":          "Items []Pet, Next PagePet",
+		"PagePetPtr":       "Items []Pet, Next PagePetPtr",
+		"Pet":              "Name string",
+		"ResultPetString":  "Value Pet, Err string",
+		"externalItem":     "ID int64",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+
+	// Recursive references point to the final struct type.
+	pagePet := schema.GetTypeByName("PagePet")
+	if next := pagePet.Fields[1].Type; next.Struct == nil || next.Struct.Type != pagePet {
+		t.Errorf("PagePet.Next: expected reference to PagePet type, got %#v", next)
+	}
+
+	// The Go types of the instance fields keep the pointers of the type arguments.
+	var goTypes []string
+	for _, name := range []string{"PagePet", "PagePetPtr"} {
+		for _, field := range schema.GetTypeByName(name).Fields {
+			for _, meta := range field.Meta {
+				if goType, ok := meta["go.field.type"]; ok {
+					goTypes = append(goTypes, fmt.Sprintf("%v.%v %v", name, field.Name, goType))
+				}
+			}
+		}
+	}
+	wantGoTypes := []string{
+		"PagePet.Items []Pet",
+		"PagePet.Next *Page[Pet]",
+		"PagePetPtr.Items []*Pet",
+		"PagePetPtr.Next *Page[*Pet]",
+	}
+	if !cmp.Equal(wantGoTypes, goTypes) {
+		t.Errorf("%s", coloredDiff(wantGoTypes, goTypes))
+	}
+}
+
+func TestInterfaceGeneric(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in      string
+		err     string
+		methods string
+	}{
+		{
+			in: `//go:webrpc json -out=/dev/null
+			type TestAPI[T any] interface {
+				Get(ctx context.Context) (item T, err error)
+			}`,
+			err: "proto.go:10:9: generic interface TestAPI can't be generated, declare its instance instead",
+		},
+		{
+			in: `type CRUD[T any] interface {
+				Get(ctx context.Context, id int64) (item *T, err error)
+				Create(ctx context.Context, item *T) (id int64, err error)
+			}
+
+			//go:webrpc json -out=/dev/null
+			type TestAPI = CRUD[Pet]`,
+			methods: "Create(item Pet) (id int64), Get(id int64) (item Pet)",
+		},
+		{
+			in: `type CRUD[T any] interface {
+				Get(ctx context.Context, id int64) (item *T, err error)
+			}
+
+			//go:webrpc json -out=/dev/null
+			type TestAPI CRUD[Pet]`,
+			methods: "Get(id int64) (item Pet)",
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+			import "context"
+
+			type Pet struct {
+				Name string
+			}
+
+			%s
+			`, tc.in)
+
+		schema, err := testParseAPI(srcCode)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s\nexpected error %q, got: %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s\n%v", tc.in, err)
+		}
+
+		var methods []string
+		for _, m := range schema.Services[0].Methods {
+			var inputs, outputs []string
+			for _, in := range m.Inputs {
+				inputs = append(inputs, fmt.Sprintf("%v %v", in.Name, in.Type))
+			}
+			for _, out := range m.Outputs {
+				outputs = append(outputs, fmt.Sprintf("%v %v", out.Name, out.Type))
+			}
+			methods = append(methods, fmt.Sprintf("%v(%v) (%v)", m.Name, strings.Join(inputs, ", "), strings.Join(outputs, ", ")))
+		}
+
+		if got := strings.Join(methods, ", "); got != tc.methods {
+			t.Errorf("%s\nexpected %q, got %q", tc.in, tc.methods, got)
+		}
+	}
+}
+
+func TestInterfaceJsonRawMessage(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+		"encoding/json"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Exec(ctx context.Context, query json.RawMessage, vars map[string]json.RawMessage) (result json.RawMessage, err error)
+		Batch(ctx context.Context, queries []json.RawMessage) (results []*json.RawMessage, err error)
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	var methods []string
+	for _, m := range schema.Services[0].Methods {
+		var inputs, outputs []string
+		for _, in := range m.Inputs {
+			inputs = append(inputs, fmt.Sprintf("%v %v", in.Name, in.Type))
+		}
+		for _, out := range m.Outputs {
+			outputs = append(outputs, fmt.Sprintf("%v %v", out.Name, out.Type))
+		}
+		methods = append(methods, fmt.Sprintf("%v(%v) (%v)", m.Name, strings.Join(inputs, ", "), strings.Join(outputs, ", ")))
+	}
+
+	want := "Batch(queries []any) (results []any), Exec(query any, vars map<string,any>) (result any)"
+	if got := strings.Join(methods, ", "); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if len(schema.Types) != 0 {
+		t.Errorf("expected no types, got %v", len(schema.Types))
+	}
+}
+
+func TestInterfaceMapOfAnyResults(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+		"encoding/json"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		GetAttrs(ctx context.Context, keys []string) (attrs map[string]any, err error)
+		GetRaw(ctx context.Context, query json.RawMessage) (result *json.RawMessage, err error)
+		GetCounts(ctx context.Context) (counts map[string]int64, err error)
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	var got []string
+	for _, m := range schema.Services[0].Methods {
+		for _, arg := range append(m.Inputs, m.Outputs...) {
+			got = append(got, fmt.Sprintf("%v.%v %v %v", m.Name, arg.Name, arg.Type, arg.TypeExtra.Meta))
+		}
+	}
+
+	want := []string{
+		"GetAttrs.keys []string []",
+		"GetAttrs.attrs map<string,any> [map[go.field.type:map[string]any]]",
+		"GetCounts.counts map<string,int64> []",
+		"GetRaw.query any [map[go.field.type:json.RawMessage] map[go.type.import:encoding/json]]",
+		"GetRaw.result any [map[go.field.type:*json.RawMessage] map[go.type.import:encoding/json]]",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+}
+
+func TestInterfaceAnyArguments(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in      string
+		methods string
+	}{
+		{
+			in:      "Log(ctx context.Context, payload any) error",
+			methods: "Log(payload any) ()",
+		},
+		{
+			in:      "Get(ctx context.Context, key string) (value interface{}, err error)",
+			methods: "Get(key string) (value any)",
+		},
+		{
+			in:      "Echo(context.Context, interface{}) (any, error)",
+			methods: "Echo(anyReq any) (any any)",
+		},
+		{
+			in:      "Batch(_ context.Context, _ []any) (_ []any, _ error)",
+			methods: "Batch(anyListReq []any) (anyList []any)",
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+		import "context"
+
+		//go:webrpc json -out=/dev/null
+		type TestAPI interface {
+			%s
+		}
+		`, tc.in)
+
+		schema := parseTestAPI(t, srcCode)
+
+		var methods []string
+		for _, m := range schema.Services[0].Methods {
+			var inputs, outputs []string
+			for _, in := range m.Inputs {
+				inputs = append(inputs, fmt.Sprintf("%v %v", in.Name, in.Type))
+			}
+			for _, out := range m.Outputs {
+				outputs = append(outputs, fmt.Sprintf("%v %v", out.Name, out.Type))
+			}
+			methods = append(methods, fmt.Sprintf("%v(%v) (%v)", m.Name, strings.Join(inputs, ", "), strings.Join(outputs, ", ")))
+		}
+
+		if got := strings.Join(methods, ", "); got != tc.methods {
+			t.Errorf("%s\nexpected %q, got %q", tc.in, tc.methods, got)
+		}
+	}
+}
+
+func TestInterfaceUnnamedArguments(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in   string
+		args []string
+		err  string
+	}{
+		{
+			in:   "GetPet(context.Context, int64) (*Pet, error)",
+			args: []string{"int64Req", "pet"},
+		},
+		{
+			in:   "ListPets(context.Context, []int64, map[string]bool) ([]*Pet, map[string]*Pet, error)",
+			args: []string{"int64ListReq", "boolMapReq", "petList", "petMap"},
+		},
+		{
+			in:   "GetPage(context.Context, *uuid.UUID, time.Time) (*Page[Pet], error)",
+			args: []string{"uuidReq", "timeReq", "page"},
+		},
+		{
+			in:  "Compare(context.Context, *Pet, *Pet) (bool, error)",
+			err: `proto.go:20:35: unnamed arguments *Pet and *Pet would both be sent as "petReq", name them explicitly`,
+		},
+		{
+			in:  "Merge(_ context.Context, _ []Pet, _ [2]*Pet) (_ []*Pet, _ error)",
+			err: `proto.go:20:38: unnamed arguments []Pet and [2]*Pet would both be sent as "petListReq", name them explicitly`,
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+		import (
+			"context"
+			"time"
+
+			"github.com/golang-cz/gospeak/internal/parser/test/uuid"
+		)
+
+		type Pet struct {
+			ID int64
+		}
+
+		type Page[T any] struct {
+			Items []T
+		}
+
+		//go:webrpc json -out=/dev/null
+		type TestAPI interface {
+			%s
+		}
+
+		var _ time.Time
+		var _ uuid.UUID
+		`, tc.in)
+
+		schema, err := testParseAPI(srcCode)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s\nexpected error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var args []string
+		for _, m := range schema.Services[0].Methods {
+			for _, arg := range append(m.Inputs, m.Outputs...) {
+				args = append(args, arg.Name)
+			}
+		}
+		if !cmp.Equal(tc.args, args) {
+			t.Errorf("%s\n%s", tc.in, coloredDiff(tc.args, args))
+		}
+	}
+}
+
+func TestInterfaceAnonymousStructs(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in      string
+		methods string
+		types   []string
+	}{
+		{
+			in: `Filter(ctx context.Context, opts struct{ Limit int }) (page *struct {
+				Total int
+			}, err error)`,
+			methods: "Filter(opts FilterOptsAnonymous) (page FilterPageAnonymous)",
+			types:   []string{"FilterOptsAnonymous{Limit int}", "FilterPageAnonymous{Total int}"},
+		},
+		{
+			in:      `Search(context.Context, *struct{ Query string }) (struct{ Total int }, error)`,
+			methods: "Search(searchReq SearchReqAnonymous) (search SearchRespAnonymous)",
+			types:   []string{"SearchReqAnonymous{Query string}", "SearchRespAnonymous{Total int}"},
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+			import "context"
+
+			//go:webrpc json -out=/dev/null
+			type TestAPI interface {
+				%s
+			}
+			`, tc.in)
+
+		schema, err := testParseAPI(srcCode)
+		if err != nil {
+			t.Fatalf("%s\n%v", tc.in, err)
+		}
+
+		var methods []string
+		for _, m := range schema.Services[0].Methods {
+			var inputs, outputs []string
+			for _, in := range m.Inputs {
+				inputs = append(inputs, fmt.Sprintf("%v %v", in.Name, in.Type))
+			}
+			for _, out := range m.Outputs {
+				outputs = append(outputs, fmt.Sprintf("%v %v", out.Name, out.Type))
+			}
+			methods = append(methods, fmt.Sprintf("%v(%v) (%v)", m.Name, strings.Join(inputs, ", "), strings.Join(outputs, ", ")))
+		}
+		if got := strings.Join(methods, ", "); got != tc.methods {
+			t.Errorf("%s\nexpected %q, got %q", tc.in, tc.methods, got)
+		}
+
+		var typeNames []string
+		for _, typ := range schema.Types {
+			var fields []string
+			for _, field := range typ.Fields {
+				fields = append(fields, fmt.Sprintf("%v %v", field.Name, field.Type))
+			}
+			typeNames = append(typeNames, fmt.Sprintf("%v{%v}", typ.Name, strings.Join(fields, ", ")))
+		}
+		if !cmp.Equal(tc.types, typeNames) {
+			t.Errorf("%s\n%s", tc.in, coloredDiff(tc.types, typeNames))
+		}
+	}
+}
+
+func TestInterfaceAllowNoContext(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Version() (version string, err error)
+		Sum(a int, b int) (sum int, err error)
+		Ping(ctx context.Context) (err error)
+		Check(err error) (ok bool, err2 error)
+		Run(ctx Context, id int64) (err error)
+	}
+
+	// Not a context.Context, despite its name.
+	type Context interface {
+		Done() <-chan struct{}
+	}
+	`
+
+	for _, allowNoContext := range []bool{false, true} {
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.AllowNoContext = allowNoContext
+
+		iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+		err = p.ParseInterfaceMethods(iface, "TestAPI")
+		if !allowNoContext {
+			if err == nil || !strings.Contains(err.Error(), "first method argument must be context.Context") {
+				t.Errorf("expected context.Context error, got: %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var methods []string
+		for _, m := range p.Schema.Services[0].Methods {
+			var inputs []string
+			for _, in := range m.Inputs {
+				inputs = append(inputs, fmt.Sprintf("%v %v", in.Name, in.Type))
+			}
+			method := fmt.Sprintf("%v(%v)", m.Name, strings.Join(inputs, ", "))
+			if annotation, ok := m.Annotations["go.context"]; ok {
+				method += " @go.context:" + annotation.Value
+			}
+			methods = append(methods, method)
+		}
+
+		want := []string{
+			"Check(err any) @go.context:none",
+			"Ping()",
+			"Run(ctx any, id int64) @go.context:none",
+			"Sum(a int, b int) @go.context:none",
+			"Version() @go.context:none",
+		}
+		if !cmp.Equal(want, methods) {
+			t.Errorf("%s", coloredDiff(want, methods))
+		}
+	}
+}
+
+func TestInterfaceStrictAny(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+		"fmt"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		GetName(ctx context.Context, id int64, meta map[string]any) (name string, err error)
+		Version() (version string, err error)
+		Describe(context.Context, fmt.Stringer) error
+	}
+
+	var _ fmt.Stringer
+	`
+
+	for _, strict := range []bool{false, true} {
+		src := srcCode
+		if strict {
+			src = strings.Replace(src, "\t\tDescribe(", "\t\t// Describe(", 1)
+		}
+
+		p, err := testParser(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Strict = strict
+		p.AllowNoContext = true
+
+		iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+		if err := p.ParseInterfaceMethods(iface, "TestAPI"); err != nil {
+			t.Fatalf("strict=%v: context.Context and error arguments must not be rejected: %v", strict, err)
+		}
+	}
+
+	// Interfaces with methods are still rejected as method arguments.
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Strict = true
+
+	iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+	err = p.ParseInterfaceMethods(iface, "TestAPI")
+	if err == nil || !strings.Contains(err.Error(), "interface fmt.Stringer would be sent as any") {
+		t.Errorf("expected fmt.Stringer error, got %v", err)
+	}
+}
+
+func TestInterfaceComposition(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/internal/parser/test/external"
+	)
+
+	type ReadAPI interface {
+		external.ReadAPI
+		Ping(ctx context.Context) (err error)
+	}
+
+	type WriteAPI interface {
+		SetItem(ctx context.Context, item *external.Item) (err error)
+		Ping(ctx context.Context) (err error)
+	}
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		ReadAPI
+		WriteAPI
+		Version(ctx context.Context) (version string, err error)
+	}
+	`
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+	if err := p.ParseInterfaceMethods(iface, "TestAPI"); err != nil {
+		t.Fatal(err)
+	}
+
+	var methods []string
+	for _, m := range p.Schema.Services[0].Methods {
+		methods = append(methods, m.Name)
+	}
+	if want := []string{"GetItem", "Ping", "SetItem", "Version"}; !cmp.Equal(want, methods) {
+		t.Errorf("%s", coloredDiff(want, methods))
+	}
+
+	want := []string{"proto.go:20:7: TestAPI.Ping() is declared by multiple interfaces: ReadAPI, WriteAPI"}
+	var warnings []string
+	for _, warning := range p.Warnings {
+		warnings = append(warnings, filepath.Base(warning))
+	}
+	if !cmp.Equal(want, warnings) {
+		t.Errorf("%s", coloredDiff(want, warnings))
+	}
+}
+
+func TestInterfaceDeprecatedMethods(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		// GetPet returns the pet.
+		//
+		// Deprecated: Use GetPetV2() instead.
+		GetPet(ctx context.Context, id int64) (name string, err error)
+
+		// GetPetV2 returns the pet.
+		GetPetV2(ctx context.Context, id int64) (name string, err error)
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	var methods []string
+	for _, m := range schema.Services[0].Methods {
+		method := m.Name + "()"
+		if annotation, ok := m.Annotations["deprecated"]; ok {
+			method += " @deprecated:" + annotation.Value
+		}
+		methods = append(methods, method)
+	}
+
+	want := []string{"GetPet() @deprecated:true", "GetPetV2()"}
+	if !cmp.Equal(want, methods) {
+		t.Errorf("%s", coloredDiff(want, methods))
+	}
+}
+
+func TestInterfaceStreamingMethods(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in     string
+		method string
+		err    string
+	}{
+		{
+			in:     "Subscribe(ctx context.Context, topic string) (events <-chan *Event, err error)",
+			method: "Subscribe(topic string) => stream (events Event) @go.stream:chan",
+		},
+		{
+			in:     "Subscribe(ctx context.Context) (<-chan []Event, error)",
+			method: "Subscribe() => stream (eventList []Event) @go.stream:chan",
+		},
+		{
+			in:     "Subscribe(ctx context.Context) (events chan string, err error)",
+			method: "Subscribe() => stream (events string) @go.stream:chan",
+		},
+		{
+			in:  "Subscribe(ctx context.Context) (events chan<- *Event, err error)",
+			err: "streaming method can't return send-only channel chan<- *github.com/golang-cz/gospeak/internal/parser/test.Event, use <-chan instead",
+		},
+		{
+			in:  "Subscribe(ctx context.Context) (events <-chan *Event, total int, err error)",
+			err: "streaming method must return a single channel or iterator and error",
+		},
+		{
+			in:  "Publish(ctx context.Context, events <-chan *Event) (err error)",
+			err: "unsupported channel type <-chan *github.com/golang-cz/gospeak/internal/parser/test.Event",
+		},
+		{
+			in:  "Subscribe(ctx context.Context) (events <-chan chan int, err error)",
+			err: "unsupported channel type chan int",
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+		import "context"
+
+		type Event struct {
+			Name string
+		}
+
+		//go:webrpc json -out=/dev/null
+		type TestAPI interface {
+			%s
+		}
+		`, tc.in)
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+		err = p.ParseInterfaceMethods(iface, "TestAPI")
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected error %q, got: %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.in, err)
+		}
+
+		m := p.Schema.Services[0].Methods[0]
+		var inputs, outputs []string
+		for _, in := range m.Inputs {
+			inputs = append(inputs, fmt.Sprintf("%v %v", in.Name, in.Type))
+		}
+		for _, out := range m.Outputs {
+			outputs = append(outputs, fmt.Sprintf("%v %v", out.Name, out.Type))
+		}
+		method := fmt.Sprintf("%v(%v) => ", m.Name, strings.Join(inputs, ", "))
+		if m.StreamOutput {
+			method += "stream "
+		}
+		method += fmt.Sprintf("(%v)", strings.Join(outputs, ", "))
+		if annotation, ok := m.Annotations["go.stream"]; ok {
+			method += " @go.stream:" + annotation.Value
+		}
+
+		if method != tc.method {
+			t.Errorf("%s: expected %q, got %q", tc.in, tc.method, method)
+		}
+	}
+}
+
+func TestInterfaceIteratorStreams(t *testing.T) {
+	t.Parallel()
+
+	if !hasReleaseTag("go1.23") {
+		t.Skip("iter package requires Go 1.23")
+	}
+
+	tt := []struct {
+		in     string
+		method string
+		err    string
+	}{
+		{
+			in:     "ListPets(ctx context.Context) (pets iter.Seq[*Pet], err error)",
+			method: "ListPets() => stream (pets Pet) @go.stream:iter.Seq",
+		},
+		{
+			in:     "ListPets(ctx context.Context) (pets iter.Seq2[*Pet, error], err error)",
+			method: "ListPets() => stream (pets Pet) @go.stream:iter.Seq2",
+		},
+		{
+			in:     "ListNames(ctx context.Context) (iter.Seq[string], error)",
+			method: "ListNames() => stream (string string) @go.stream:iter.Seq",
+		},
+		{
+			in:  "ListPets(ctx context.Context) (pets iter.Seq2[int, *Pet], err error)",
+			err: "streaming method can't return iter.Seq2[int, *github.com/golang-cz/gospeak/internal/parser/test.Pet], use iter.Seq2[V, error] or iter.Seq[V] instead",
+		},
+		{
+			in:  "ListPets(ctx context.Context) (pets iter.Seq[*Pet], total int, err error)",
+			err: "streaming method must return a single channel or iterator and error",
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+		import (
+			"context"
+			"iter"
+		)
+
+		type Pet struct {
+			Name string
+		}
+
+		//go:webrpc json -out=/dev/null
+		type TestAPI interface {
+			%s
+		}
+		`, tc.in)
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+		err = p.ParseInterfaceMethods(iface, "TestAPI")
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected error %q, got: %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.in, err)
+		}
+
+		m := p.Schema.Services[0].Methods[0]
+		var outputs []string
+		for _, out := range m.Outputs {
+			outputs = append(outputs, fmt.Sprintf("%v %v", out.Name, out.Type))
+		}
+		method := fmt.Sprintf("%v() => ", m.Name)
+		if m.StreamOutput {
+			method += "stream "
+		}
+		method += fmt.Sprintf("(%v)", strings.Join(outputs, ", "))
+		if annotation, ok := m.Annotations["go.stream"]; ok {
+			method += " @go.stream:" + annotation.Value
+		}
+
+		if method != tc.method {
+			t.Errorf("%s: expected %q, got %q", tc.in, tc.method, method)
+		}
+	}
+}
+
+// Reports whether the Go toolchain running the tests has the release tag, ie. go1.23.
+func hasReleaseTag(tag string) bool {
+	for _, releaseTag := range build.Default.ReleaseTags {
+		if releaseTag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInterfaceIgnoredMethods(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+		"database/sql"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		// GetPet returns the pet.
+		GetPet(ctx context.Context, id int64) (name string, err error)
+
+		// Tx returns the database transaction of the request.
+		//
+		//gospeak:ignore
+		Tx(ctx context.Context) *sql.Tx
+
+		//gospeak:ignore
+		Close()
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	var methods []string
+	for _, m := range schema.Services[0].Methods {
+		methods = append(methods, m.Name)
+	}
+	if want := []string{"GetPet"}; !cmp.Equal(want, methods) {
+		t.Errorf("%s", coloredDiff(want, methods))
+	}
+}
+
+func TestInterfaceNameDirectives(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/enum"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		//gospeak:name GetPet
+		GetPetV2(ctx context.Context, id int64) (pet *PetV2, err error)
+
+		ListPets(ctx context.Context, status Status) (pets []*PetV2, err error)
+	}
+
+	// PetV2 is a pet.
+	//
+	//gospeak:name Pet
+	type PetV2 struct {
+		ID     int64
+		Status Status
+		Parent *PetV2
+	}
+
+	// approved
+	// pending
+	//
+	//gospeak:name PetStatus
+	type Status enum.Int
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	var methods []string
+	for _, m := range schema.Services[0].Methods {
+		method := fmt.Sprintf("%v(", m.Name)
+		for _, in := range m.Inputs {
+			method += fmt.Sprintf("%v %v", in.Name, in.Type)
+		}
+		method += ") => ("
+		for _, out := range m.Outputs {
+			method += fmt.Sprintf("%v %v", out.Name, out.Type)
+		}
+		method += ")"
+		if annotation, ok := m.Annotations["go.method.name"]; ok {
+			method += " @go.method.name:" + annotation.Value
+		}
+		methods = append(methods, method)
+	}
+	wantMethods := []string{
+		"GetPet(id int64) => (pet Pet) @go.method.name:GetPetV2",
+		"ListPets(status PetStatus) => (pets []Pet)",
+	}
+	if !cmp.Equal(wantMethods, methods) {
+		t.Errorf("%s", coloredDiff(wantMethods, methods))
+	}
+
+	var types []string
+	for _, typ := range schema.Types {
+		var fields []string
+		for _, field := range typ.Fields {
+			if field.Type != nil {
+				fields = append(fields, fmt.Sprintf("%v %v", field.Name, field.Type))
+			}
+		}
+		types = append(types, fmt.Sprintf("%v %v{%v} %v", typ.Kind, typ.Name, strings.Join(fields, ", "), typ.Meta))
+	}
+	wantTypes := []string{
+		"enum PetStatus{} [map[go.type.name:Status]]",
+		"struct Pet{ID int64, Status PetStatus, Parent Pet} [map[go.type.name:PetV2]]",
+	}
+	if !cmp.Equal(wantTypes, types) {
+		t.Errorf("%s", coloredDiff(wantTypes, types))
+	}
+}
+
+func TestInterfaceNameDirectiveErrors(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in  string
+		err string
+	}{
+		{
+			in: `//gospeak:name Get-Pet
+			GetPet(ctx context.Context) (pet *Pet, err error)`,
+			err: `GetPet(): invalid //gospeak:name directive "Get-Pet", expected method name`,
+		},
+		{
+			in:  `GetPet(ctx context.Context) (pet *PetV2, err error)`,
+			err: `PetV2: invalid //gospeak:name directive "", expected type name`,
+		},
+		{
+			in:  `GetPet(ctx context.Context) (pet *Pet, petV3 *PetV3, err error)`,
+			err: `PetV3: type name Pet given by //gospeak:name directive is already taken`,
+		},
+		{
+			in: `//gospeak:name ListPets
+			GetPets(ctx context.Context) error
+			ListPets(ctx context.Context) error`,
+			err: `ListPets(): method name ListPets is already taken by GetPets()`,
+		},
+		{
+			in: `//gospeak:name FindPet
+			GetPet(ctx context.Context) error
+			//gospeak:name FindPet
+			LookupPet(ctx context.Context) error`,
+			err: `LookupPet(): method name FindPet is already taken by GetPet()`,
+		},
+		{
+			in: `//gospeak:name Listpets
+			GetPets(ctx context.Context) error
+			ListPets(ctx context.Context) error`,
+			err: `ListPets(): method name ListPets is already taken by GetPets()`,
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := fmt.Sprintf(`package test
+
+		import "context"
+
+		//go:webrpc json -out=/dev/null
+		type TestAPI interface {
+			%s
+		}
+
+		type Pet struct{}
+
+		//gospeak:name
+		type PetV2 struct{}
+
+		//gospeak:name Pet
+		type PetV3 struct{}
+		`, tc.in)
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+		err = p.ParseInterfaceMethods(iface, "TestAPI")
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected error %q, got: %v", tc.in, tc.err, err)
+		}
+	}
+}
+
+func TestInterfaceSplitServices(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/internal/parser/test/external"
+	)
+
+	// AdminAPI manages the items.
+	type AdminAPI interface {
+		SetItem(ctx context.Context, item *external.Item) (err error)
+		DeleteItem(ctx context.Context, id int64) (err error)
+	}
+
+	// API of the item store.
+	//
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		//gospeak:service
+		AdminAPI
+
+		//gospeak:service Public
+		external.ReadAPI
+
+		Version(ctx context.Context) (version string, err error)
+	}
+	`
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+	if err := p.ParseInterfaceMethods(iface, "TestAPI"); err != nil {
+		t.Fatal(err)
+	}
+	schema := p.Schema
+
+	var services []string
+	for _, service := range schema.Services {
+		var methods []string
+		for _, m := range service.Methods {
+			methods = append(methods, m.Name)
+		}
+		services = append(services, fmt.Sprintf("%v(%v) %q", service.Name, strings.Join(methods, ", "), service.Comments))
+	}
+	want := []string{
+		`TestAPI(Version) ["API of the item store."]`,
+		`AdminAPI(DeleteItem, SetItem) ["AdminAPI manages the items."]`,
+		`Public(GetItem) ["ReadAPI is declared outside of the schema package."]`,
+	}
+	if !cmp.Equal(want, services) {
+		t.Errorf("%s", coloredDiff(want, services))
+	}
+
+	var types []string
+	for _, typ := range schema.Types {
+		types = append(types, typ.Name)
+	}
+	if want := []string{"externalItem"}; !cmp.Equal(want, types) {
+		t.Errorf("%s", coloredDiff(want, types))
+	}
+}
+
+func TestInterfaceOptionalArguments(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+		"time"
+
+		"github.com/golang-cz/gospeak/internal/parser/test/uuid"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Schedule(ctx context.Context, at *time.Time, id *uuid.UUID, after time.Time, ids []*uuid.UUID) (next *time.Time, prev time.Time, err error)
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	var args []string
+	for _, m := range schema.Services[0].Methods {
+		for _, arg := range append(m.Inputs, m.Outputs...) {
+			args = append(args, fmt.Sprintf("%v %v optional=%v", arg.Name, arg.Type, arg.Optional))
+		}
+	}
+
+	want := []string{
+		"at timestamp optional=true",
+		"id string optional=true",
+		"after timestamp optional=false",
+		"ids []string optional=false",
+		"next timestamp optional=true",
+		"prev timestamp optional=false",
+	}
+	if !cmp.Equal(want, args) {
+		t.Errorf("%s", coloredDiff(want, args))
+	}
+}
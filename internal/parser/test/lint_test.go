@@ -0,0 +1,79 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang-cz/gospeak/internal/parser"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	// TestAPI is documented.
+	//
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		// GetItem is documented.
+		GetItem(ctx context.Context, filter map[string]any) (item *Item, err error)
+		ListItems(ctx context.Context) (items map[string]*Item, err error)
+	}
+
+	type Item struct {
+		ID    int64
+		Data  any
+		Attrs map[string][]interface{}
+	}
+	`
+
+	tt := []struct {
+		rules []string
+		want  []string
+	}{
+		{},
+		{
+			rules: []string{"no-any"},
+			want: []string{
+				"no-any: field Item.Data is any",
+				"no-any: field Item.Attrs is map<string,[]any>",
+				"no-any: argument filter of TestAPI.GetItem() is map<string,any>",
+			},
+		},
+		{
+			rules: []string{"no-map-results"},
+			want: []string{
+				"no-map-results: result items of TestAPI.ListItems() is map<string,Item>",
+			},
+		},
+		{
+			rules: []string{"require-comments"},
+			want: []string{
+				"require-comments: struct Item has no description",
+				"require-comments: method TestAPI.ListItems() has no description",
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		schema := parseTestAPI(t, srcCode)
+
+		var got []string
+		if err := parser.Lint(schema, tc.rules); err != nil {
+			_, violations, _ := strings.Cut(err.Error(), ":\n  ")
+			got = strings.Split(violations, "\n  ")
+		}
+		if !cmp.Equal(tc.want, got) {
+			t.Errorf("%v\n%s", tc.rules, coloredDiff(tc.want, got))
+		}
+	}
+
+	schema := parseTestAPI(t, srcCode)
+	if err := parser.Lint(schema, []string{"no-maps"}); err == nil || err.Error() != `unknown lint rule "no-maps"` {
+		t.Errorf("expected unknown lint rule error, got %v", err)
+	}
+}
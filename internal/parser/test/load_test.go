@@ -0,0 +1,380 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang-cz/gospeak"
+	"github.com/google/go-cmp/cmp"
+)
+
+// Writes the files into a new temporary directory.
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGoWorkspace(t *testing.T) {
+	// Go rejects -mod=mod in the workspace mode.
+	t.Setenv("GOFLAGS", "")
+
+	// Service interface in module "example.com/api" referencing types of
+	// the sibling module "example.com/model" via go.work, without any replace directive.
+	dir := writeTestFiles(t, map[string]string{
+		"go.work": "go 1.20\n\nuse (\n\t./api\n\t./model\n)\n",
+
+		"api/go.mod": "module example.com/api\n\ngo 1.20\n\nrequire example.com/model v0.0.0\n",
+		"api/api.go": `package api
+
+import (
+	"context"
+
+	"example.com/model"
+)
+
+//go:webrpc json -out=/dev/null
+type API interface {
+	GetItem(ctx context.Context, id int64) (item *model.Item, err error)
+}
+`,
+
+		"model/go.mod": "module example.com/model\n\ngo 1.20\n",
+		"model/model.go": `package model
+
+type Item struct {
+	ID int64
+}
+`,
+	})
+
+	apiDir := filepath.Join(dir, "api")
+
+	for _, goWork := range []string{"", filepath.Join(dir, "go.work")} {
+		targets, err := gospeak.ParseWithOptions(apiDir, gospeak.Options{GoWork: goWork})
+		if err != nil {
+			t.Fatalf("gowork=%q: %v", goWork, err)
+		}
+		if len(targets) != 1 || targets[0].Schema.GetTypeByName("modelItem") == nil {
+			t.Errorf("gowork=%q: expected modelItem type from the sibling module", goWork)
+		}
+	}
+
+	if _, err := gospeak.ParseWithOptions(apiDir, gospeak.Options{GoWork: "off"}); err == nil {
+		t.Errorf("gowork=off: expected error, since example.com/model can't be resolved")
+	}
+}
+
+func TestVendoredTypes(t *testing.T) {
+	// Go rejects -mod=mod with the vendor directory.
+	t.Setenv("GOFLAGS", "")
+
+	apiSource := `package api
+
+import (
+	"context"
+
+	"example.com/model"
+)
+
+//go:webrpc json -out=/dev/null
+type API interface {
+	GetItem(ctx context.Context, id int64) (item *Item, err error)
+}
+
+type Item struct {
+	ID    int64
+	Model *model.Item
+}
+`
+	modelSource := `package model
+
+type Item struct {
+	ID int64
+}
+`
+
+	tt := []struct {
+		name   string
+		env    map[string]string
+		files  map[string]string
+		apiDir string
+	}{
+		{
+			name: "module",
+			files: map[string]string{
+				"api/go.mod":                            "module example.com/api\n\ngo 1.20\n\nrequire example.com/model v1.0.0\n",
+				"api/api.go":                            apiSource,
+				"api/vendor/modules.txt":                "# example.com/model v1.0.0\n## explicit\nexample.com/model\n",
+				"api/vendor/example.com/model/model.go": modelSource,
+			},
+			apiDir: "api",
+		},
+		{
+			// Packages of vendored dependencies are loaded as example.com/api/vendor/example.com/model.
+			name: "GOPATH",
+			env:  map[string]string{"GO111MODULE": "off"},
+			files: map[string]string{
+				"src/example.com/api/api.go":                            apiSource,
+				"src/example.com/api/vendor/example.com/model/model.go": modelSource,
+			},
+			apiDir: "src/example.com/api",
+		},
+	}
+
+	for _, tc := range tt {
+		dir := writeTestFiles(t, tc.files)
+		t.Setenv("GOPATH", dir)
+		for key, value := range tc.env {
+			t.Setenv(key, value)
+		}
+
+		targets, err := gospeak.ParseWithOptions(filepath.Join(dir, tc.apiDir), gospeak.Options{})
+		if err != nil {
+			t.Fatalf("%v: %v", tc.name, err)
+		}
+
+		field := targets[0].Schema.GetTypeByName("Item").Fields[1]
+		var goTypeImport string
+		for _, meta := range field.TypeExtra.Meta {
+			if value, ok := meta["go.type.import"]; ok {
+				goTypeImport = value.(string)
+			}
+		}
+		if goTypeImport != "example.com/model" {
+			t.Errorf("%v: expected go.type.import %q, got %q", tc.name, "example.com/model", goTypeImport)
+		}
+	}
+}
+
+func TestBuildConstraints(t *testing.T) {
+	t.Parallel()
+
+	dir := writeTestFiles(t, map[string]string{
+		"api/go.mod": "module example.com/api\n\ngo 1.20\n",
+		"api/api.go": `//go:build !windows
+
+package api
+
+import "context"
+
+//go:webrpc json -out=/dev/null
+type API interface {
+	GetItem(ctx context.Context, id int64) (item *Item, err error)
+}
+`,
+		"api/api_windows.go": `package api
+
+import "context"
+
+//go:webrpc json -out=/dev/null
+type API interface {
+	GetItem(ctx context.Context, id int64) (item *Item, err error)
+	GetPlatform(ctx context.Context) (platform *Platform, err error)
+}
+`,
+		"api/item.go": `//go:build !integration
+
+package api
+
+type Item struct {
+	ID int64
+}
+`,
+		"api/item_integration.go": `//go:build integration
+
+package api
+
+type Item struct {
+	ID      int64
+	Fixture string
+}
+`,
+		"api/platform.go": `package api
+
+type Platform struct {
+	OS string
+}
+`,
+	})
+
+	tt := []struct {
+		opts   gospeak.Options
+		fields []string
+		types  []string
+	}{
+		{
+			opts:   gospeak.Options{GOOS: "linux"},
+			fields: []string{"ID"},
+			types:  []string{"Item"},
+		},
+		{
+			opts:   gospeak.Options{GOOS: "linux", BuildTags: []string{"integration"}},
+			fields: []string{"ID", "Fixture"},
+			types:  []string{"Item"},
+		},
+		{
+			opts:   gospeak.Options{GOOS: "windows", GOARCH: "amd64"},
+			fields: []string{"ID"},
+			types:  []string{"Item", "Platform"},
+		},
+	}
+
+	for _, tc := range tt {
+		targets, err := gospeak.ParseWithOptions(filepath.Join(dir, "api"), tc.opts)
+		if err != nil {
+			t.Fatalf("%+v: %v", tc.opts, err)
+		}
+		webrpcSchema := targets[0].Schema
+
+		var fields []string
+		for _, field := range webrpcSchema.GetTypeByName("Item").Fields {
+			fields = append(fields, field.Name)
+		}
+		if !cmp.Equal(tc.fields, fields) {
+			t.Errorf("%+v: unexpected fields\n%s", tc.opts, coloredDiff(tc.fields, fields))
+		}
+
+		var types []string
+		for _, typ := range webrpcSchema.Types {
+			types = append(types, typ.Name)
+		}
+		if !cmp.Equal(tc.types, types) {
+			t.Errorf("%+v: unexpected types\n%s", tc.opts, coloredDiff(tc.types, types))
+		}
+	}
+}
+
+func TestNoCgo(t *testing.T) {
+	t.Parallel()
+
+	dir := writeTestFiles(t, map[string]string{
+		"api/go.mod": "module example.com/api\n\ngo 1.20\n",
+		"api/api.go": `package api
+
+import "context"
+
+//go:webrpc json -out=/dev/null
+type API interface {
+	GetItem(ctx context.Context, id int64) (item *Item, err error)
+}
+
+type Item struct {
+	ID int64
+}
+`,
+		"api/native.go": `package api
+
+// #include "missing.h"
+import "C"
+
+func native() { C.missing() }
+`,
+	})
+
+	targets, err := gospeak.ParseWithOptions(filepath.Join(dir, "api"), gospeak.Options{NoCgo: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ := targets[0].Schema.GetTypeByName("Item"); typ == nil {
+		t.Errorf("expected Item type")
+	}
+}
+
+func TestEmbeddedExternalStruct(t *testing.T) {
+	// Go rejects -mod=mod in the workspace mode.
+	t.Setenv("GOFLAGS", "")
+
+	// Fields of the flattened pagination.Page keep the import of the module they're declared in.
+	dir := writeTestFiles(t, map[string]string{
+		"go.work": "go 1.20\n\nuse (\n\t./api\n\t./pagination\n)\n",
+
+		"api/go.mod": "module example.com/api\n\ngo 1.20\n\nrequire example.com/pagination v0.0.0\n",
+		"api/api.go": `package api
+
+import (
+	"context"
+
+	"example.com/pagination"
+)
+
+//go:webrpc json -out=/dev/null
+type API interface {
+	ListItems(ctx context.Context) (items *Items, err error)
+}
+
+type Items struct {
+	pagination.Page
+	*pagination.Cursor
+	Items []int64
+}
+`,
+
+		"pagination/go.mod": "module example.com/pagination\n\ngo 1.20\n",
+		"pagination/page.go": `package pagination
+
+type Page struct {
+	Size   int
+	Sort   []Sort
+	Filter map[string]*Filter
+	Kind   Kind
+}
+
+type Cursor struct {
+	After string
+	Next  *Page
+}
+
+type Sort struct{ Column string }
+
+type Filter struct{ Value string }
+
+type Kind string
+`,
+	})
+
+	targets, err := gospeak.ParseWithOptions(filepath.Join(dir, "api"), gospeak.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, field := range targets[0].Schema.GetTypeByName("Items").Fields {
+		var goType, goImport string
+		for _, meta := range field.TypeExtra.Meta {
+			if value, ok := meta["go.field.type"]; ok {
+				goType = value.(string)
+			}
+			if value, ok := meta["go.type.import"]; ok {
+				goImport = value.(string)
+			}
+		}
+		got = append(got, strings.TrimSpace(fmt.Sprintf("%v %v %v", field.Name, goType, goImport)))
+	}
+
+	want := []string{
+		"Size int",
+		"Sort []pagination.Sort example.com/pagination",
+		"Filter map[string]*pagination.Filter example.com/pagination",
+		"Kind pagination.Kind example.com/pagination",
+		"After string",
+		"Next *pagination.Page example.com/pagination",
+		"Items []int64",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+}
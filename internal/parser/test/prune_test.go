@@ -0,0 +1,85 @@
+package test
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPruneTypes(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/enum"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		GetPet(ctx context.Context, id int64) (pet *Pet, err error)
+		ListTags(ctx context.Context) (tags map[Kind][]*Tag, err error)
+	}
+
+	type Pet struct {
+		ID     int64
+		Status Status
+		Owner  *Owner
+	}
+
+	type Owner struct {
+		Name string
+		Pets []*Pet
+	}
+
+	type Tag struct {
+		Name string
+	}
+
+	type Helper struct {
+		Color Color
+	}
+
+	// available
+	// sold
+	type Status enum.Int
+
+	// cat
+	// dog
+	type Kind enum.Int
+
+	// red
+	// blue
+	type Color enum.Int
+	`
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CollectEnums(); err != nil {
+		t.Fatal(err)
+	}
+	if err := parseStruct(p, "Helper"); err != nil {
+		t.Fatal(err)
+	}
+
+	iface := p.Pkg.Types.Scope().Lookup("TestAPI").Type().Underlying().(*types.Interface)
+	if err := p.ParseInterfaceMethods(iface, "TestAPI"); err != nil {
+		t.Fatal(err)
+	}
+
+	p.PruneTypes()
+
+	var typeNames []string
+	for _, typ := range p.Schema.Types {
+		typeNames = append(typeNames, typ.Name)
+	}
+	want := []string{"Kind", "Status", "Owner", "Pet", "Tag"}
+	if !cmp.Equal(want, typeNames) {
+		t.Errorf("%s", coloredDiff(want, typeNames))
+	}
+}
@@ -1,6 +1,10 @@
 package test
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -20,6 +24,8 @@ func TestStructFieldJsonTags(t *testing.T) {
 		goImport string
 		optional bool
 
+		jsonString string // {"json.string": "int64"} meta
+
 		Struct *schema.VarStructType
 	}
 
@@ -39,6 +45,14 @@ func TestStructFieldJsonTags(t *testing.T) {
 			in:  "ID int64 `json:\"-\"`", // ignored in JSON
 			out: nil,
 		},
+		{
+			in:  "Dash int64 `json:\"-,\"`", // field named "-" in JSON
+			out: &field{name: "-", expr: "int64", t: schema.T_Int64, goName: "Dash", goType: "int64", jsonTag: "-,"},
+		},
+		{
+			in:  "ID int64 `json:\"a\\\"b\"`", // invalid name, default name in JSON
+			out: &field{name: "ID", expr: "int64", t: schema.T_Int64, goName: "ID", goType: "int64", jsonTag: `a"b`},
+		},
 		{
 			in:  "ID *int64", // optional field
 			out: &field{name: "ID", expr: "int64", t: schema.T_Int64, goName: "ID", goType: "*int64", optional: true},
@@ -49,11 +63,11 @@ func TestStructFieldJsonTags(t *testing.T) {
 		},
 		{
 			in:  "ID int64 `json:\",string\"`", // string type in JSON
-			out: &field{name: "ID", expr: "string", t: schema.T_String, goName: "ID", goType: "int64", jsonTag: ",string"},
+			out: &field{name: "ID", expr: "string", t: schema.T_String, goName: "ID", goType: "int64", jsonTag: ",string", jsonString: "int64"},
 		},
 		{
 			in:  "ID int64 `json:\"id,string\"`", // renamed field with string type in JSON
-			out: &field{name: "id", expr: "string", t: schema.T_String, goName: "ID", goType: "int64", jsonTag: "id,string"},
+			out: &field{name: "id", expr: "string", t: schema.T_String, goName: "ID", goType: "int64", jsonTag: "id,string", jsonString: "int64"},
 		},
 		{
 			in:  "ID int64 `json:\",omitempty\"`", // optional in JSON
@@ -61,7 +75,23 @@ func TestStructFieldJsonTags(t *testing.T) {
 		},
 		{
 			in:  "ID int64 `json:\"id,string,omitempty\"`", // optional with string type in JSON
-			out: &field{name: "id", expr: "string", t: schema.T_String, goName: "ID", goType: "*int64", jsonTag: "id,string,omitempty", optional: true},
+			out: &field{name: "id", expr: "string", t: schema.T_String, goName: "ID", goType: "*int64", jsonTag: "id,string,omitempty", optional: true, jsonString: "int64"},
+		},
+		{
+			in:  "ID *int64 `json:\"id,string\"`", // optional with string type in JSON
+			out: &field{name: "id", expr: "string", t: schema.T_String, goName: "ID", goType: "*int64", jsonTag: "id,string", optional: true, jsonString: "int64"},
+		},
+		{
+			in:  "Active bool `json:\",string\"`", // string type in JSON
+			out: &field{name: "Active", expr: "string", t: schema.T_String, goName: "Active", goType: "bool", jsonTag: ",string", jsonString: "bool"},
+		},
+		{
+			in:  "Name string `json:\",string\"`", // JSON-encoded string in JSON string
+			out: &field{name: "Name", expr: "string", t: schema.T_String, goName: "Name", goType: "string", jsonTag: ",string", jsonString: "string"},
+		},
+		{
+			in:  "Timeout time.Duration `json:\",string\"`", // string type in JSON
+			out: &field{name: "Timeout", expr: "string", t: schema.T_String, goName: "Timeout", goType: "time.Duration", jsonTag: ",string", jsonString: "int64"},
 		},
 		{
 			in:  "CreatedAt time.Time",
@@ -77,7 +107,15 @@ func TestStructFieldJsonTags(t *testing.T) {
 		},
 		{
 			in:  "NumberString Number `json:\",string\"`", // string type in JSON
-			out: &field{name: "NumberString", expr: "string", t: schema.T_String, goName: "NumberString", goType: "Number", jsonTag: ",string"},
+			out: &field{name: "NumberString", expr: "string", t: schema.T_String, goName: "NumberString", goType: "Number", jsonTag: ",string", jsonString: "int"},
+		},
+		{
+			in:  "NumberPtrString *Number `json:\",string\"`", // optional with string type in JSON
+			out: &field{name: "NumberPtrString", expr: "string", t: schema.T_String, goName: "NumberPtrString", goType: "*Number", jsonTag: ",string", optional: true, jsonString: "int"},
+		},
+		{
+			in:  "LocaleString Locale `json:\",string\"`", // string option ignored for encoding.TextMarshaler
+			out: &field{name: "LocaleString", expr: "string", t: schema.T_String, goName: "LocaleString", goType: "Locale", jsonTag: ",string"},
 		},
 		{
 			in:  "LocaleString Locale",
@@ -111,6 +149,19 @@ func TestStructFieldJsonTags(t *testing.T) {
 				Struct:   &schema.VarStructType{Name: "emptyStruct", Type: &schema.Type{Kind: "struct", Name: "emptyStruct"}},
 			},
 		},
+		{
+			in: "Empty empty.Struct `json:\",string\"`", // string option ignored for structs
+			out: &field{
+				name:     "Empty",
+				expr:     "emptyStruct",
+				t:        schema.T_Struct,
+				jsonTag:  ",string",
+				goName:   "Empty",
+				goType:   "empty.Struct",
+				goImport: "github.com/golang-cz/gospeak/internal/parser/test/empty",
+				Struct:   &schema.VarStructType{Name: "emptyStruct", Type: &schema.Type{Kind: "struct", Name: "emptyStruct"}},
+			},
+		},
 		//{
 		//	in:  "Embedded",
 		//	out: &field{name: "Embedded", expr: "Embedded", t: schema.T_Struct, goName: "Embedded", goType: "Embedded"},
@@ -147,6 +198,9 @@ func TestStructFieldJsonTags(t *testing.T) {
 			if tc.out.jsonTag != "" {
 				fields[0].TypeExtra.Meta = append(fields[0].TypeExtra.Meta, schema.TypeFieldMeta{"go.tag.json": tc.out.jsonTag})
 			}
+			if tc.out.jsonString != "" {
+				fields[0].TypeExtra.Meta = append(fields[0].TypeExtra.Meta, schema.TypeFieldMeta{"json.string": tc.out.jsonString})
+			}
 		}
 
 		want := &schema.Type{
@@ -225,3 +279,1996 @@ func TestStructSliceField(t *testing.T) {
 		}
 	}
 }
+
+func TestStructDocComments(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface{
+		Test(ctx context.Context) (tst *TestStruct, err error)
+	}
+
+	// TestStruct is documented.
+	// It spans two lines.
+	type TestStruct struct {
+		// ID is the primary key.
+		ID int64
+
+		Name string // Line comments are not doc comments.
+
+		// Nested is documented too.
+		Nested *Nested
+	}
+
+	type (
+		// Nested is declared in a type group.
+		Nested struct {
+			Value int
+		}
+	)
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	want := map[string][]string{
+		"TestStruct":        {"TestStruct is documented.", "It spans two lines."},
+		"TestStruct.ID":     {"ID is the primary key."},
+		"TestStruct.Name":   nil,
+		"TestStruct.Nested": {"Nested is documented too."},
+		"Nested":            {"Nested is declared in a type group."},
+		"Nested.Value":      nil,
+	}
+
+	got := map[string][]string{}
+	for _, typ := range schema.Types {
+		got[typ.Name] = typ.Comments
+		for _, field := range typ.Fields {
+			got[typ.Name+"."+field.Name] = field.Comments
+		}
+	}
+
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+}
+
+func TestStructTimeField(t *testing.T) {
+	t.Parallel()
+
+	timestamp := &schema.VarType{Expr: "timestamp", Type: schema.T_Timestamp}
+	timestampList := &schema.VarType{Expr: "[]timestamp", Type: schema.T_List, List: &schema.VarListType{Elem: timestamp}}
+
+	tt := []struct {
+		in       string
+		out      *schema.VarType
+		goType   string
+		optional bool
+	}{
+		{
+			in:     "T time.Time",
+			out:    timestamp,
+			goType: "time.Time",
+		},
+		{
+			in:       "T *time.Time",
+			out:      timestamp,
+			goType:   "*time.Time",
+			optional: true,
+		},
+		{
+			in:     "T []time.Time",
+			out:    timestampList,
+			goType: "[]time.Time",
+		},
+		{
+			in:     "T [2]time.Time",
+			out:    timestampList,
+			goType: "[2]time.Time",
+		},
+		{
+			in: "T map[string]time.Time",
+			out: &schema.VarType{
+				Expr: "map<string,timestamp>",
+				Type: schema.T_Map,
+				Map:  &schema.VarMapType{Key: &schema.VarType{Expr: "string", Type: schema.T_String}, Value: timestamp},
+			},
+			goType: "map[string]time.Time",
+		},
+		{
+			in: "T map[string][]time.Time",
+			out: &schema.VarType{
+				Expr: "map<string,[]timestamp>",
+				Type: schema.T_Map,
+				Map:  &schema.VarMapType{Key: &schema.VarType{Expr: "string", Type: schema.T_String}, Value: timestampList},
+			},
+			goType: "map[string][]time.Time",
+		},
+	}
+
+	for _, tc := range tt {
+		want := &schema.Type{
+			Kind: "struct",
+			Name: "TestStruct",
+			Fields: []*schema.TypeField{
+				{
+					Name: "T",
+					Type: tc.out,
+					TypeExtra: schema.TypeExtra{
+						Optional: tc.optional,
+						Meta: []schema.TypeFieldMeta{
+							{"go.field.name": "T"},
+							{"go.field.type": tc.goType},
+						},
+					},
+				},
+			},
+		}
+
+		srcCode := genCodeWithStructField("TestStruct", tc.in)
+		got := parseTestStructCode(t, srcCode)
+
+		if !cmp.Equal(want, got) {
+			t.Log(srcCode)
+			t.Errorf("%s\n%s\n", tc.in, coloredDiff(want, got))
+		}
+	}
+}
+
+func TestStructDurationField(t *testing.T) {
+	t.Parallel()
+
+	int64Type := &schema.VarType{Expr: "int64", Type: schema.T_Int64}
+	stringType := &schema.VarType{Expr: "string", Type: schema.T_String}
+
+	tt := []struct {
+		in       string
+		duration string
+		out      *schema.VarType
+		goType   string
+		format   string
+		optional bool
+	}{
+		{
+			in:     "T time.Duration",
+			out:    int64Type,
+			goType: "time.Duration",
+			format: "ns",
+		},
+		{
+			in:       "T time.Duration",
+			duration: "ms",
+			out:      int64Type,
+			goType:   "time.Duration",
+			format:   "ms",
+		},
+		{
+			in:       "T *time.Duration",
+			duration: "string",
+			out:      stringType,
+			goType:   "*time.Duration",
+			format:   "string",
+			optional: true,
+		},
+	}
+
+	for _, tc := range tt {
+		want := &schema.Type{
+			Kind: "struct",
+			Name: "TestStruct",
+			Fields: []*schema.TypeField{
+				{
+					Name: "T",
+					Type: tc.out,
+					TypeExtra: schema.TypeExtra{
+						Optional: tc.optional,
+						Meta: []schema.TypeFieldMeta{
+							{"go.field.name": "T"},
+							{"go.field.type": tc.goType},
+							{"go.duration": tc.format},
+						},
+					},
+				},
+			},
+		}
+
+		srcCode := genCodeWithStructField("TestStruct", tc.in)
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Duration = tc.duration
+
+		if err := parseStruct(p, "TestStruct"); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := p.Schema.GetTypeByName("TestStruct"); !cmp.Equal(want, got) {
+			t.Errorf("%s (duration=%q)\n%s\n", tc.in, tc.duration, coloredDiff(want, got))
+		}
+	}
+}
+
+func TestStructFieldNames(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in         string
+		fieldNames string
+		name       string
+		goName     string
+		goType     string
+		jsonTag    string
+		optional   bool
+	}{
+		{in: "UserID int64", goName: "UserID", name: "UserID", goType: "int64"},
+		{in: "UserID int64", goName: "UserID", fieldNames: "camelCase", name: "userID", goType: "int64", jsonTag: "userID"},
+		{in: "UserID int64", goName: "UserID", fieldNames: "snake_case", name: "user_id", goType: "int64", jsonTag: "user_id"},
+		{in: "UserID int64 `json:\",omitempty\"`", goName: "UserID", fieldNames: "snake_case", name: "user_id", goType: "*int64", jsonTag: "user_id,omitempty", optional: true},
+		{in: "UserID int64 `json:\"uid\"`", goName: "UserID", fieldNames: "snake_case", name: "uid", goType: "int64", jsonTag: "uid"}, // explicit name wins
+		{in: "ID int64", goName: "ID", fieldNames: "camelCase", name: "id", goType: "int64", jsonTag: "id"},
+	}
+
+	for _, tc := range tt {
+		meta := []schema.TypeFieldMeta{
+			{"go.field.name": tc.goName},
+			{"go.field.type": tc.goType},
+		}
+		if tc.jsonTag != "" {
+			meta = append(meta, schema.TypeFieldMeta{"go.tag.json": tc.jsonTag})
+		}
+
+		want := &schema.Type{
+			Kind: "struct",
+			Name: "TestStruct",
+			Fields: []*schema.TypeField{
+				{
+					Name: tc.name,
+					Type: &schema.VarType{Expr: "int64", Type: schema.T_Int64},
+					TypeExtra: schema.TypeExtra{
+						Optional: tc.optional,
+						Meta:     meta,
+					},
+				},
+			},
+		}
+
+		srcCode := genCodeWithStructField("TestStruct", tc.in)
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.FieldNames = tc.fieldNames
+
+		if err := parseStruct(p, "TestStruct"); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := p.Schema.GetTypeByName("TestStruct"); !cmp.Equal(want, got) {
+			t.Errorf("%s (field names=%q)\n%s\n", tc.in, tc.fieldNames, coloredDiff(want, got))
+		}
+	}
+}
+
+func TestStructTags(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in   string
+		meta []schema.TypeFieldMeta
+	}{
+		{
+			in: "Email string `json:\"email\" validate:\"required,max=64,email\"`",
+			meta: []schema.TypeFieldMeta{
+				{"go.field.name": "Email"},
+				{"go.field.type": "string"},
+				{"go.tag.json": "email"},
+				{"go.tag.validate": "required,max=64,email"},
+			},
+		},
+		{
+			in: "Email string `validate:\"omitempty,email\"`",
+			meta: []schema.TypeFieldMeta{
+				{"go.field.name": "Email"},
+				{"go.field.type": "string"},
+				{"go.tag.validate": "omitempty,email"},
+			},
+		},
+		{
+			in: "Email int64 `json:\",string\" validate:\"gt=0\"`",
+			meta: []schema.TypeFieldMeta{
+				{"go.field.name": "Email"},
+				{"go.field.type": "int64"},
+				{"go.tag.json": ",string"},
+				{"json.string": "int64"},
+				{"go.tag.validate": "gt=0"},
+			},
+		},
+		{
+			in: "Email string `json:\"email\" db:\"email_address\" yaml:\"mail\" db:\"ignored\"`",
+			meta: []schema.TypeFieldMeta{
+				{"go.field.name": "Email"},
+				{"go.field.type": "string"},
+				{"go.tag.json": "email"},
+				{"go.tag.db": "email_address"},
+				{"go.tag.yaml": "mail"},
+			},
+		},
+		{
+			in: "Email string `validate:\"\"`",
+			meta: []schema.TypeFieldMeta{
+				{"go.field.name": "Email"},
+				{"go.field.type": "string"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := genCodeWithStructField("TestStruct", tc.in)
+		got := parseTestStructCode(t, srcCode)
+
+		if len(got.Fields) != 1 {
+			t.Fatalf("%s: expected 1 field, got %v", tc.in, len(got.Fields))
+		}
+		if !cmp.Equal(tc.meta, got.Fields[0].TypeExtra.Meta) {
+			t.Errorf("%s\n%s", tc.in, coloredDiff(tc.meta, got.Fields[0].TypeExtra.Meta))
+		}
+	}
+}
+
+func TestStructWebrpcTag(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in        string
+		optional  bool
+		sensitive bool
+		err       string
+	}{
+		{in: "Pet *Struct", optional: true},
+		{in: "Pet *Struct `webrpc:\"required\"`", optional: false},
+		{in: "Pet *Struct `json:\",omitempty\" webrpc:\"required\"`", optional: false},
+		{in: "Pet Struct `webrpc:\"optional\"`", optional: true},
+		{in: "Pet Struct `webrpc:\"required\"`", optional: false},
+		{in: "Pet int64 `json:\",string\" webrpc:\"optional\"`", optional: true},
+		{in: "Pet *Struct `webrpc:\"required,sensitive\"`", optional: false, sensitive: true},
+		{in: "Pet string `webrpc:\"sensitive\"`", sensitive: true},
+		{in: "Pet string `log:\"-\"`", sensitive: true},
+		{in: "Pet string `log:\"pet\"`"},
+		{in: "Pet Struct `webrpc:\"maybe\"`", err: `invalid webrpc:"maybe" struct tag, expected required, optional or sensitive: TestStruct.Pet`},
+	}
+
+	for _, tc := range tt {
+		srcCode := genCodeWithStructField("TestStruct", tc.in)
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = parseStruct(p, "TestStruct")
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.in, err)
+		}
+
+		got := p.Schema.GetTypeByName("TestStruct")
+		if len(got.Fields) != 1 {
+			t.Fatalf("%s: expected 1 field, got %v", tc.in, len(got.Fields))
+		}
+		if got.Fields[0].Optional != tc.optional {
+			t.Errorf("%s: expected optional=%v, got %v", tc.in, tc.optional, got.Fields[0].Optional)
+		}
+
+		sensitive := false
+		for _, meta := range got.Fields[0].TypeExtra.Meta {
+			if meta["sensitive"] == "true" {
+				sensitive = true
+			}
+		}
+		if sensitive != tc.sensitive {
+			t.Errorf("%s: expected sensitive=%v, got %v", tc.in, tc.sensitive, sensitive)
+		}
+	}
+}
+
+func TestStructDefaultValues(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in       string
+		value    string
+		comments []string
+		err      string
+	}{
+		{in: "Age int `default:\"10\"`", value: "10"},
+		{in: "// Age of the pet.\n// default: 10\nAge int", value: "10", comments: []string{"Age of the pet."}},
+		{in: "// default: 10\nAge int `default:\"12\"`", value: "12"},
+		{in: "Name string `default:\"fluffy\"`", value: `"fluffy"`},
+		{in: "// default: \"fluffy\"\nName string", value: `"fluffy"`},
+		{in: "// default: 10\nName string", value: `"10"`},
+		{in: "// default: [\"a\", \"b\"]\nTags []string", value: `["a", "b"]`},
+		{in: "// Name of the pet.\nName string", comments: []string{"Name of the pet."}},
+		{in: "Age int `default:\"ten\"`", err: `invalid default value of Age: "ten" is not a JSON value: TestStruct.Age`},
+		{in: "// default: \"fluffy\nName string", err: `invalid default value of Name: invalid string "fluffy`},
+	}
+
+	for _, tc := range tt {
+		srcCode := genCodeWithStructField("TestStruct", tc.in)
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = parseStruct(p, "TestStruct")
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.in, err)
+		}
+
+		field := p.Schema.GetTypeByName("TestStruct").Fields[0]
+
+		var value string
+		for _, meta := range field.TypeExtra.Meta {
+			if v, ok := meta["default"]; ok {
+				value = fmt.Sprint(v)
+			}
+		}
+		if value != tc.value {
+			t.Errorf("%s: expected default %q, got %q", tc.in, tc.value, value)
+		}
+		if !cmp.Equal(tc.comments, field.Comments) {
+			t.Errorf("%s\n%s", tc.in, coloredDiff(tc.comments, field.Comments))
+		}
+	}
+}
+
+func TestStructExampleValues(t *testing.T) {
+	t.Parallel()
+
+	srcCode := genCodeWithStructField("TestStruct", `
+		// Name of the pet.
+		// example: "fluffy"
+		Name string
+
+		// default: 1
+		// example: 3
+		Age int
+
+		// Deprecated: Use Labels instead.
+		Tags []string `+"`example:\"[\\\"cute\\\"]\"`"+`
+	`)
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parseStruct(p, "TestStruct"); err != nil {
+		t.Fatal(err)
+	}
+
+	type field struct {
+		name     string
+		comments []string
+		meta     []schema.TypeFieldMeta
+	}
+
+	var got []field
+	for _, f := range p.Schema.GetTypeByName("TestStruct").Fields {
+		got = append(got, field{name: f.Name, comments: f.Comments, meta: f.TypeExtra.Meta[2:]})
+	}
+
+	want := []field{
+		{name: "Name", comments: []string{"Name of the pet."}, meta: []schema.TypeFieldMeta{{"example": `"fluffy"`}}},
+		{name: "Age", meta: []schema.TypeFieldMeta{{"default": "1"}, {"example": "3"}}},
+		{name: "Tags", comments: []string{"Deprecated: Use Labels instead."}, meta: []schema.TypeFieldMeta{{"go.tag.example": `["cute"]`}, {"deprecated": "true"}, {"example": `["cute"]`}}},
+	}
+	if !cmp.Equal(want, got, cmp.AllowUnexported(field{})) {
+		t.Errorf("%s", coloredDiff(want, got, cmp.AllowUnexported(field{})))
+	}
+}
+
+func TestStructErrorPositions(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in  string
+		err string
+	}{
+		{in: "Owners map[*Struct]string", err: "unsupported map key type empty.Struct, expected string or integer: TestStruct.Owners"},
+		{in: "Age int `default:\"ten\"`", err: `invalid default value of Age: "ten" is not a JSON value: TestStruct.Age`},
+		{in: "Events chan string", err: "Events: unsupported channel type chan string"},
+	}
+
+	for _, tc := range tt {
+		srcCode := genCodeWithStructField("TestStruct", tc.in)
+		line := strings.Count(srcCode[:strings.Index(srcCode, tc.in)], "\n") + 1
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = parseStruct(p, "TestStruct")
+		if err == nil {
+			t.Errorf("%s: expected error", tc.in)
+			continue
+		}
+		position := fmt.Sprintf("proto.go:%v:3: ", line)
+		if !strings.Contains(err.Error(), position+tc.err) {
+			t.Errorf("%s: expected error %q, got %q", tc.in, position+tc.err, err)
+		}
+	}
+}
+
+func TestStructStrictAny(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "fmt"
+
+	type Shape interface {
+		Area() float64
+	}
+
+	var _ fmt.Stringer
+
+	type Raw struct{}
+
+	func (r Raw) MarshalJSON() ([]byte, error) { return nil, nil }
+	func (r *Raw) UnmarshalJSON(b []byte) error { return nil }
+
+	type TestStruct struct {
+		Data   any
+		Meta   map[string]interface{}
+		Shape  Shape
+		Label  fmt.Stringer
+		Inline interface{ Area() float64 }
+		Raw    Raw
+	}
+	`
+
+	tt := []struct {
+		field string
+		err   string
+	}{
+		{field: "Data"},
+		{field: "Meta"},
+		{field: "Shape", err: "interface Shape would be sent as any"},
+		{field: "Label", err: "interface fmt.Stringer would be sent as any"},
+		{field: "Inline", err: "interface interface{Area() float64} would be sent as any"},
+		{field: "Raw", err: "Raw implementing json.Marshaler would be sent as any"},
+	}
+
+	for _, tc := range tt {
+		// Keep the tested field only.
+		src := srcCode
+		for _, other := range tt {
+			if other.field != tc.field {
+				src = strings.Replace(src, "\t\t"+other.field+" ", "\t\t// "+other.field+" ", 1)
+			}
+		}
+
+		for _, strict := range []bool{false, true} {
+			p, err := testParser(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.Strict = strict
+
+			err = parseStruct(p, "TestStruct")
+			if !strict || tc.err == "" {
+				if err != nil {
+					t.Errorf("%v (strict=%v): unexpected error: %v", tc.field, strict, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err+", use a concrete type or disable --strict: TestStruct."+tc.field) {
+				t.Errorf("%v (strict=%v): expected error %q, got %v", tc.field, strict, tc.err, err)
+			}
+		}
+	}
+}
+
+func TestStructOverriddenFieldWarnings(t *testing.T) {
+	t.Parallel()
+
+	// Same as encoding/json, the least nested field wins regardless of the declaration order.
+	// Of the equally nested fields, the tagged one wins, otherwise none of them is sent.
+	tt := []struct {
+		fields   string
+		want     []string
+		warnings []string
+	}{
+		{
+			fields: "Base\n\t\tName  string\n\t\tTitle string `json:\"ID\"`",
+			want:   []string{"ID string", "Name string"},
+			warnings: []string{
+				`proto.go:19:3: TestStruct.Title overrides ID (proto.go:4:3), both are sent as JSON field "ID"`,
+				`proto.go:18:3: TestStruct.Name overrides Name (proto.go:5:3), both are sent as JSON field "Name"`,
+			},
+		},
+		{
+			fields: "Name  string\n\t\tTitle string `json:\"ID\"`\n\t\tBase",
+			want:   []string{"Name string", "ID string"},
+			warnings: []string{
+				`proto.go:17:3: TestStruct.Name overrides Name (proto.go:5:3), both are sent as JSON field "Name"`,
+				`proto.go:18:3: TestStruct.Title overrides ID (proto.go:4:3), both are sent as JSON field "ID"`,
+			},
+		},
+		{
+			fields: "Base\n\t\tOther",
+			want:   []string{"ID int64"},
+			warnings: []string{
+				`proto.go:9:3: TestStruct.Name conflicts with Name (proto.go:5:3) at the same depth, JSON field "Name" is not sent`,
+			},
+		},
+		{
+			fields: "Other\n\t\tBase",
+			want:   []string{"ID int64"},
+			warnings: []string{
+				`proto.go:5:3: TestStruct.Name conflicts with Name (proto.go:9:3) at the same depth, JSON field "Name" is not sent`,
+			},
+		},
+		{
+			fields: "Base\n\t\tTagged",
+			want:   []string{"ID int64", "Name bool"},
+			warnings: []string{
+				`proto.go:13:3: TestStruct.Name overrides Name (proto.go:5:3), both are sent as JSON field "Name"`,
+			},
+		},
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range tt {
+		srcCode := `package test
+
+	type Base struct {
+		ID   int64
+		Name string
+	}
+
+	type Other struct {
+		Name bool
+	}
+
+	type Tagged struct {
+		Name bool ` + "`json:\"Name\"`" + `
+	}
+
+	type TestStruct struct {
+		` + tc.fields + `
+	}
+	`
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := parseStruct(p, "TestStruct"); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, field := range p.Schema.GetTypeByName("TestStruct").Fields {
+			got = append(got, fmt.Sprintf("%v %v", field.Name, field.Type))
+		}
+		if !cmp.Equal(tc.want, got) {
+			t.Errorf("%s\n%s", tc.fields, coloredDiff(tc.want, got))
+		}
+
+		var warnings []string
+		for _, warning := range p.Warnings {
+			warnings = append(warnings, strings.ReplaceAll(warning, wd+string(filepath.Separator), ""))
+		}
+		if !cmp.Equal(tc.warnings, warnings) {
+			t.Errorf("%s\n%s", tc.fields, coloredDiff(tc.warnings, warnings))
+		}
+	}
+}
+
+func TestStructFieldOrder(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "time"
+
+	type Base struct {
+		ID        int64
+		Name      string
+		CreatedAt time.Time
+	}
+
+	type Audit struct {
+		UpdatedBy string
+		Title     int64
+	}
+
+	type TestStruct struct {
+		Title string
+		Base
+		Kind  string
+		Name  bool   ` + "`json:\"Name\"`" + `
+		Audit
+		Notes string
+	}
+	`
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parseStruct(p, "TestStruct"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Overridden fields stay in place of the fields they override. Audit.Title is
+	// nested deeper than TestStruct.Title, so it's not sent.
+	var got []string
+	for _, field := range p.Schema.GetTypeByName("TestStruct").Fields {
+		got = append(got, fmt.Sprintf("%v %v", field.Name, field.Type))
+	}
+	want := []string{
+		"Title string", // TestStruct.Title
+		"ID int64",
+		"Name bool", // TestStruct.Name
+		"CreatedAt timestamp",
+		"Kind string",
+		"UpdatedBy string",
+		"Notes string",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+}
+
+func TestStructUnexportedFieldWarnings(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	type audit struct {
+		CreatedBy string
+	}
+
+	type TestStruct struct {
+		audit
+		ID       int64
+		name     string ` + "`json:\"name,omitempty\"`" + `
+		password string ` + "`json:\"-\"`" + `
+		internal bool
+	}
+	`
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parseStruct(p, "TestStruct"); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, warning := range p.Warnings {
+		got = append(got, strings.ReplaceAll(warning, wd+string(filepath.Separator), ""))
+	}
+	want := []string{
+		`proto.go:10:3: TestStruct.name is unexported, it won't be sent as JSON field despite its json:"name,omitempty" tag`,
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+}
+
+func TestStructInternalTypes(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in            string
+		internalTypes string
+		meta          []schema.TypeFieldMeta
+		err           string
+	}{
+		{
+			in: "ID *uuid.UUID",
+			meta: []schema.TypeFieldMeta{
+				{"go.field.name": "ID"},
+				{"go.field.type": "*uuid.UUID"},
+				{"go.type.import": "github.com/golang-cz/gospeak/internal/parser/test/uuid"},
+			},
+		},
+		{
+			in:            "ID *uuid.UUID",
+			internalTypes: "error",
+			err:           "type uuid.UUID of internal package github.com/golang-cz/gospeak/internal/parser/test/uuid can't be imported by the generated code outside of github.com/golang-cz/gospeak, see --internal-types=copy: TestStruct.ID",
+		},
+		{
+			in:            "ID *uuid.UUID `json:\"id\"`",
+			internalTypes: "copy",
+			meta: []schema.TypeFieldMeta{
+				{"go.field.name": "ID"},
+				{"go.tag.json": "id"},
+			},
+		},
+		{
+			in:            "Items []empty.Struct",
+			internalTypes: "copy",
+			meta: []schema.TypeFieldMeta{
+				{"go.field.name": "Items"},
+			},
+		},
+		{
+			in:            "Count int64",
+			internalTypes: "error",
+			meta: []schema.TypeFieldMeta{
+				{"go.field.name": "Count"},
+				{"go.field.type": "int64"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := genCodeWithStructField("TestStruct", tc.in)
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.InternalTypes = tc.internalTypes
+
+		err = parseStruct(p, "TestStruct")
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.in, err)
+		}
+
+		got := p.Schema.GetTypeByName("TestStruct").Fields[0].TypeExtra.Meta
+		if !cmp.Equal(tc.meta, got) {
+			t.Errorf("%s (internal types=%q)\n%s", tc.in, tc.internalTypes, coloredDiff(tc.meta, got))
+		}
+	}
+}
+
+func TestStructByteArrayField(t *testing.T) {
+	t.Parallel()
+
+	stringType := &schema.VarType{Expr: "string", Type: schema.T_String}
+	byteList := &schema.VarType{Expr: "[]byte", Type: schema.T_List, List: &schema.VarListType{Elem: &schema.VarType{Expr: "byte", Type: schema.T_Byte}}}
+
+	tt := []struct {
+		in         string
+		byteArrays string
+		out        *schema.VarType
+		goType     string
+		format     string
+	}{
+		{
+			in:     "T [4]byte",
+			out:    byteList,
+			goType: "[4]byte",
+		},
+		{
+			in:         "T [4]byte",
+			byteArrays: "hex",
+			out:        stringType,
+			goType:     "[4]byte",
+			format:     "hex",
+		},
+		{
+			in:         "T Hash",
+			byteArrays: "base64",
+			out:        stringType,
+			goType:     "Hash",
+			format:     "base64",
+		},
+		{
+			in:         "T [][4]byte",
+			byteArrays: "hex",
+			out:        &schema.VarType{Expr: "[]string", Type: schema.T_List, List: &schema.VarListType{Elem: stringType}},
+			goType:     "[][4]byte",
+		},
+		{
+			in:         "T uuid.UUID", // implements encoding.TextMarshaler
+			byteArrays: "hex",
+			out:        stringType,
+			goType:     "uuid.UUID",
+		},
+	}
+
+	for _, tc := range tt {
+		meta := []schema.TypeFieldMeta{
+			{"go.field.name": "T"},
+			{"go.field.type": tc.goType},
+		}
+		if strings.HasPrefix(tc.goType, "uuid.") {
+			meta = append(meta, schema.TypeFieldMeta{"go.type.import": "github.com/golang-cz/gospeak/internal/parser/test/uuid"})
+		}
+		if tc.format != "" {
+			meta = append(meta, schema.TypeFieldMeta{"go.byte_array": tc.format})
+		}
+
+		want := &schema.Type{
+			Kind: "struct",
+			Name: "TestStruct",
+			Fields: []*schema.TypeField{
+				{
+					Name:      "T",
+					Type:      tc.out,
+					TypeExtra: schema.TypeExtra{Meta: meta},
+				},
+			},
+		}
+
+		srcCode := genCodeWithStructField("TestStruct", tc.in) + "\ntype Hash [32]byte\n"
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.ByteArrays = tc.byteArrays
+
+		if err := parseStruct(p, "TestStruct"); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := p.Schema.GetTypeByName("TestStruct"); !cmp.Equal(want, got) {
+			t.Errorf("%s (byte-arrays=%q)\n%s\n", tc.in, tc.byteArrays, coloredDiff(want, got))
+		}
+	}
+}
+
+func TestStructBigNumberFields(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+		"math/big"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Test(ctx context.Context) (tst *TestStruct, err error)
+	}
+
+	type TestStruct struct {
+		Int    *big.Int
+		Float  big.Float
+		Rat    *big.Rat
+		Prices map[string]*big.Rat
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	testStruct := schema.GetTypeByName("TestStruct")
+	if testStruct == nil {
+		t.Fatal("TestStruct not found")
+	}
+
+	var got []string
+	for _, f := range testStruct.Fields {
+		got = append(got, fmt.Sprintf("%v %v %v", f.Name, f.Type, f.TypeExtra.Meta[1]["go.field.type"]))
+	}
+
+	want := []string{
+		"Int any *big.Int", // Sent as a number literal by its MarshalJSON().
+		"Float string big.Float",
+		"Rat string *big.Rat",
+		"Prices map<string,string> map[string]*big.Rat",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+
+	if len(schema.Types) != 1 {
+		t.Errorf("expected TestStruct type only, got %v types", len(schema.Types))
+	}
+}
+
+func TestStructNetAddrFields(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+		"net"
+		"net/netip"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Test(ctx context.Context, ip netip.Addr) (tst *TestStruct, err error)
+	}
+
+	type TestStruct struct {
+		IP       net.IP
+		Addr     netip.Addr
+		AddrPort *netip.AddrPort
+		Prefixes []netip.Prefix
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	testStruct := schema.GetTypeByName("TestStruct")
+	if testStruct == nil {
+		t.Fatal("TestStruct not found")
+	}
+
+	var got []string
+	for _, f := range testStruct.Fields {
+		got = append(got, fmt.Sprintf("%v %v", f.Name, f.Type))
+	}
+	for _, in := range schema.Services[0].Methods[0].Inputs {
+		got = append(got, fmt.Sprintf("%v %v", in.Name, in.Type))
+	}
+
+	want := []string{
+		"IP string",
+		"Addr string",
+		"AddrPort string",
+		"Prefixes []string",
+		"ip string",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+}
+
+func TestStructURLFields(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "net/url"
+
+	type TestStruct struct {
+		Homepage url.URL
+		Callback *url.URL
+	}
+	`
+
+	for _, urlStrings := range []bool{false, true} {
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.URLStrings = urlStrings
+
+		// Objects by default, same as encoding/json, but their url.Userinfo can't be sent.
+		err = parseStruct(p, "TestStruct")
+		if !urlStrings {
+			if err == nil || !strings.Contains(err.Error(), "type url.Userinfo has no exported fields, it would always be serialized as {}, use --url-strings to send url.URL as string") {
+				t.Errorf("url-strings=%v: expected url.Userinfo error, got %v", urlStrings, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("url-strings=%v: %v", urlStrings, err)
+		}
+
+		var got []string
+		for _, f := range p.Schema.GetTypeByName("TestStruct").Fields {
+			got = append(got, fmt.Sprintf("%v %v", f.Name, f.Type))
+		}
+
+		want := []string{"Homepage string", "Callback string"}
+		if !cmp.Equal(want, got) {
+			t.Errorf("url-strings=%v:\n%s", urlStrings, coloredDiff(want, got))
+		}
+	}
+}
+
+func TestStructTypeMappings(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "github.com/golang-cz/gospeak/internal/parser/test/external"
+
+	type TestStruct struct {
+		Item  external.Item
+		Items []*external.Item
+	}
+	`
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.TypeMappings = map[string]schema.CoreType{
+		"github.com/golang-cz/gospeak/internal/parser/test/external.Item": schema.T_String,
+	}
+
+	if err := parseStruct(p, "TestStruct"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &schema.Type{
+		Kind: "struct",
+		Name: "TestStruct",
+		Fields: []*schema.TypeField{
+			{
+				Name: "Item",
+				Type: &schema.VarType{Expr: "string", Type: schema.T_String},
+				TypeExtra: schema.TypeExtra{
+					Meta: []schema.TypeFieldMeta{
+						{"go.field.name": "Item"},
+						{"go.field.type": "external.Item"},
+						{"go.type.import": "github.com/golang-cz/gospeak/internal/parser/test/external"},
+					},
+				},
+			},
+			{
+				Name: "Items",
+				Type: &schema.VarType{Expr: "[]string", Type: schema.T_List, List: &schema.VarListType{Elem: &schema.VarType{Expr: "string", Type: schema.T_String}}},
+				TypeExtra: schema.TypeExtra{
+					Meta: []schema.TypeFieldMeta{
+						{"go.field.name": "Items"},
+						{"go.field.type": "[]*external.Item"},
+						{"go.type.import": "github.com/golang-cz/gospeak/internal/parser/test/external"},
+					},
+				},
+			},
+		},
+	}
+	if got := p.Schema.GetTypeByName("TestStruct"); !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+	if p.Schema.GetTypeByName("externalItem") != nil {
+		t.Errorf("unexpected externalItem struct")
+	}
+}
+
+func TestStructNullableFields(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"github.com/golang-cz/gospeak/internal/parser/test/external"
+		"github.com/golang-cz/gospeak/nullable"
+	)
+
+	type Option[T any] struct {
+		value *T
+	}
+
+	type Wrapper[T any] struct {
+		Value T
+	}
+
+	type TestStruct struct {
+		Name    nullable.Nullable[string]
+		Count   *nullable.Nullable[int64]
+		Item    nullable.Nullable[external.Item]
+		Tags    []nullable.Nullable[string]
+		Option  Option[bool]
+		Wrapper Wrapper[bool]
+	}
+	`
+
+	p, err := testParser(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.NullableTypes = map[string]struct{}{
+		"github.com/golang-cz/gospeak/internal/parser/test.Option": {},
+	}
+
+	if err := parseStruct(p, "TestStruct"); err != nil {
+		t.Fatal(err)
+	}
+
+	type field struct {
+		name     string
+		expr     string
+		optional bool
+	}
+
+	var got []field
+	for _, f := range p.Schema.GetTypeByName("TestStruct").Fields {
+		got = append(got, field{name: f.Name, expr: f.Type.String(), optional: f.Optional})
+	}
+
+	want := []field{
+		{name: "Name", expr: "string", optional: true},
+		{name: "Count", expr: "int64", optional: true},
+		{name: "Item", expr: "externalItem", optional: true},
+		{name: "Tags", expr: "[]string"},
+		{name: "Option", expr: "bool", optional: true},
+		{name: "Wrapper", expr: "WrapperBool"},
+	}
+	if !cmp.Equal(want, got, cmp.AllowUnexported(field{})) {
+		t.Errorf("%s", coloredDiff(want, got, cmp.AllowUnexported(field{})))
+	}
+
+	for _, name := range []string{"Nullable", "NullableString", "OptionBool"} {
+		if p.Schema.GetTypeByName(name) != nil {
+			t.Errorf("unexpected %v struct in schema", name)
+		}
+	}
+}
+
+func TestStructMapKeyFields(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		in  string
+		out string
+		err string
+	}{
+		{in: "M map[UserID]Profile", out: "map<int64,Profile>"},
+		{in: "M map[*UserID]Profile", err: "map key"},
+		{in: "M map[Name]int", out: "map<string,int>"},
+		{in: "M map[Locale]int", out: "map<string,int>"}, // implements encoding.TextMarshaler
+		{in: "M map[uuid.UUID][]UserID", out: "map<string,[]int64>"},
+		{in: "M map[float64]int", err: "map key"},
+		{in: "M map[Point]int", err: "map key"},
+	}
+
+	for _, tc := range tt {
+		srcCode := genCodeWithStructField("TestStruct", tc.in) + `
+			type UserID int64
+			type Name string
+			type Profile struct {
+				ID UserID
+			}
+			type Point struct {
+				X, Y int
+			}
+			`
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = parseStruct(p, "TestStruct")
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: expected error %q, got: %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.in, err)
+		}
+
+		if got := p.Schema.GetTypeByName("TestStruct").Fields[0].Type.String(); got != tc.out {
+			t.Errorf("%s: expected %q, got %q", tc.in, tc.out, got)
+		}
+	}
+}
+
+func TestStructPointerToCompositeFields(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Test(ctx context.Context) (tst *TestStruct, err error)
+	}
+
+	type Item struct {
+		ID int64
+	}
+
+	type Items []*Item
+
+	type TestStruct struct {
+		Items     *[]Item
+		ItemPtrs  *[]*Item
+		Named     *Items
+		Counts    *map[string]int
+		Array     *[2]int
+		Nested    *map[string]*[]int
+		Anonymous *struct {
+			Name string
+		}
+		Tags *[]string ` + "`" + `json:"tags,omitempty"` + "`" + `
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	testStruct := schema.GetTypeByName("TestStruct")
+	if testStruct == nil {
+		t.Fatal("TestStruct not found")
+	}
+
+	var got []string
+	for _, f := range testStruct.Fields {
+		got = append(got, fmt.Sprintf("%v %v optional=%v %v", f.Name, f.Type, f.Optional, f.TypeExtra.Meta[1]["go.field.type"]))
+	}
+
+	want := []string{
+		"Items []Item optional=true *[]Item",
+		"ItemPtrs []Item optional=true *[]*Item",
+		"Named []Item optional=true *Items",
+		"Counts map<string,int> optional=true *map[string]int",
+		"Array []int optional=true *[2]int",
+		"Nested map<string,[]int> optional=true *map[string]*[]int",
+		"Anonymous AnonymousAnonymous optional=true *struct{Name string}",
+		"tags []string optional=true *[]string",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+}
+
+func TestStructEmbeddedFields(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import "context"
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Test(ctx context.Context) (tst *TestStruct, err error)
+	}
+
+	type Base struct {
+		ID   int64
+		Name string
+	}
+
+	type Audit struct {
+		CreatedBy string
+	}
+
+	type audit struct {
+		UpdatedBy string
+	}
+
+	type Label string
+
+	type TestStruct struct {
+		*Base
+		Audit ` + "`json:\"audit\"`" + `
+		audit
+		Label
+		Title string
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	testStruct := schema.GetTypeByName("TestStruct")
+	if testStruct == nil {
+		t.Fatal("TestStruct not found")
+	}
+
+	var got []string
+	for _, f := range testStruct.Fields {
+		got = append(got, fmt.Sprintf("%v %v optional=%v", f.Name, f.Type, f.Optional))
+	}
+
+	want := []string{
+		"ID int64 optional=true",
+		"Name string optional=true",
+		"audit Audit optional=false",
+		"UpdatedBy string optional=false",
+		"Label string optional=false",
+		"Title string optional=false",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+
+	if base := schema.GetTypeByName("Base"); base != nil && base.Fields[0].Optional {
+		t.Errorf("Base.ID should not be optional")
+	}
+}
+
+func TestStructEmbeddedPointerOverride(t *testing.T) {
+	t.Parallel()
+
+	// The fields of *Base are nested same as the fields of Base, see TestStructOverriddenFieldWarnings.
+	tt := []struct {
+		fields string
+		want   []string
+	}{
+		{
+			fields: "*Base\n\t\tName bool",
+			want:   []string{"ID int64 optional=true", "Name bool optional=false"},
+		},
+		{
+			fields: "Name bool\n\t\t*Base",
+			want:   []string{"Name bool optional=false", "ID int64 optional=true"},
+		},
+		{
+			fields: "*Base\n\t\tOther",
+			want:   []string{"ID int64 optional=true"},
+		},
+		{
+			fields: "Other\n\t\t*Base",
+			want:   []string{"ID int64 optional=true"},
+		},
+		{
+			// Unexported *base is flattened too, same as in encoding/json.
+			fields: "*base\n\t\tName bool",
+			want:   []string{"ID int64 optional=true", "Name bool optional=false"},
+		},
+		{
+			fields: "*Wrapper\n\t\tOther",
+			want:   []string{"ID int64 optional=true", "Name bool optional=false"},
+		},
+	}
+
+	for _, tc := range tt {
+		srcCode := `package test
+
+	type Base struct {
+		ID   int64
+		Name string
+	}
+
+	type Other struct {
+		Name bool
+	}
+
+	type base struct {
+		ID   int64
+		Name string
+	}
+
+	type Wrapper struct {
+		*Base
+	}
+
+	type TestStruct struct {
+		` + tc.fields + `
+	}
+	`
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := parseStruct(p, "TestStruct"); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, f := range p.Schema.GetTypeByName("TestStruct").Fields {
+			got = append(got, fmt.Sprintf("%v %v optional=%v", f.Name, f.Type, f.Optional))
+		}
+		if !cmp.Equal(tc.want, got) {
+			t.Errorf("%s\n%s", tc.fields, coloredDiff(tc.want, got))
+		}
+	}
+}
+
+func TestStructChanField(t *testing.T) {
+	t.Parallel()
+
+	tt := []string{
+		"Events chan int",
+		"Events <-chan *Embedded",
+		"Events []chan int",
+		"Events map[string]chan int",
+	}
+
+	for _, in := range tt {
+		srcCode := genCodeWithStructField("TestStruct", in)
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(fmt.Errorf("error creating test parser: %w", err))
+		}
+
+		err = parseStruct(p, "TestStruct")
+		if err == nil {
+			t.Errorf("%s: expected error", in)
+			continue
+		}
+
+		// Field is declared on line 12 of the generated proto.go file.
+		if !strings.Contains(err.Error(), "proto.go:12:") || !strings.Contains(err.Error(), "channels can't be serialized to JSON") {
+			t.Errorf("%s: unexpected error: %v", in, err)
+		}
+	}
+}
+
+func TestStructSkipUnsupportedFields(t *testing.T) {
+	t.Parallel()
+
+	tt := []string{
+		"Callback func()",
+		"Events chan int",
+		"Ptr unsafe.Pointer",
+		"Callbacks map[string]func() error",
+	}
+
+	for _, in := range tt {
+		srcCode := genCodeWithStructField("TestStruct", in+"\n\t\tID int64")
+		srcCode = strings.Replace(srcCode, `"time"`, `"time"
+		"unsafe"`, 1) + "\nvar _ unsafe.Pointer"
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(fmt.Errorf("error creating test parser: %w", err))
+		}
+		p.SkipUnsupportedFields = true
+
+		if err := parseStruct(p, "TestStruct"); err != nil {
+			t.Errorf("%s: unexpected error: %v", in, err)
+			continue
+		}
+
+		var fields []string
+		for _, typ := range p.Schema.Types {
+			if typ.Name == "TestStruct" {
+				for _, field := range typ.Fields {
+					fields = append(fields, field.Name)
+				}
+			}
+		}
+
+		if !cmp.Equal([]string{"ID"}, fields) {
+			t.Errorf("%s\n%s", in, coloredDiff([]string{"ID"}, fields))
+		}
+
+		// Field is declared on line 13, the extra "unsafe" import shifts it by one.
+		if len(p.Warnings) != 1 || !strings.Contains(p.Warnings[0], "proto.go:13:") {
+			t.Errorf("%s: expected one warning at proto.go:13, got %v", in, p.Warnings)
+		}
+	}
+}
+
+func TestStructMapOfAnyField(t *testing.T) {
+	t.Parallel()
+
+	str := &schema.VarType{Expr: "string", Type: schema.T_String}
+	anyType := &schema.VarType{Expr: "any", Type: schema.T_Any}
+	mapOfAny := &schema.VarType{Expr: "map<string,any>", Type: schema.T_Map, Map: &schema.VarMapType{Key: str, Value: anyType}}
+
+	tt := []struct {
+		in       string
+		out      *schema.VarType
+		goType   string
+		goImport string
+		optional bool
+	}{
+		{
+			in:       "M json.RawMessage",
+			out:      anyType,
+			goType:   "json.RawMessage",
+			goImport: "encoding/json",
+		},
+		{
+			in:       "M map[string]json.RawMessage",
+			out:      mapOfAny,
+			goType:   "map[string]json.RawMessage",
+			goImport: "encoding/json",
+		},
+		{
+			in:       "M map[Key]json.RawMessage",
+			out:      mapOfAny,
+			goType:   "map[Key]json.RawMessage",
+			goImport: "encoding/json",
+		},
+		{
+			in:     "M Metadata",
+			out:    mapOfAny,
+			goType: "Metadata",
+		},
+		{
+			in:       "M *Metadata",
+			out:      mapOfAny,
+			goType:   "*Metadata",
+			optional: true,
+		},
+		{
+			in:     "M map[string]any",
+			out:    mapOfAny,
+			goType: "map[string]any",
+		},
+		{
+			in:     "M map[string]interface{}",
+			out:    mapOfAny,
+			goType: "map[string]interface{}",
+		},
+		{
+			in:     "M map[string]map[string]any",
+			out:    &schema.VarType{Expr: "map<string,map<string,any>>", Type: schema.T_Map, Map: &schema.VarMapType{Key: str, Value: mapOfAny}},
+			goType: "map[string]map[string]any",
+		},
+		{
+			in:       "M map[string][]json.RawMessage",
+			out:      &schema.VarType{Expr: "map<string,[]any>", Type: schema.T_Map, Map: &schema.VarMapType{Key: str, Value: &schema.VarType{Expr: "[]any", Type: schema.T_List, List: &schema.VarListType{Elem: anyType}}}},
+			goType:   "map[string][]json.RawMessage",
+			goImport: "encoding/json",
+		},
+	}
+
+	for _, tc := range tt {
+		want := &schema.Type{
+			Kind: "struct",
+			Name: "TestStruct",
+			Fields: []*schema.TypeField{
+				{
+					Name: "M",
+					Type: tc.out,
+					TypeExtra: schema.TypeExtra{
+						Meta: []schema.TypeFieldMeta{
+							{"go.field.name": "M"},
+							{"go.field.type": tc.goType},
+						},
+						Optional: tc.optional,
+					},
+				},
+			},
+		}
+		if tc.goImport != "" {
+			want.Fields[0].TypeExtra.Meta = append(want.Fields[0].TypeExtra.Meta, schema.TypeFieldMeta{"go.type.import": tc.goImport})
+		}
+
+		srcCode := genCodeWithStructField("TestStruct", tc.in)
+		srcCode = strings.Replace(srcCode, `"time"`, `"time"
+		"encoding/json"`, 1) + `
+	var _ json.RawMessage
+
+	// Extensible metadata, values are passed through as raw JSON.
+	type Metadata map[string]json.RawMessage
+
+	type Key string
+	`
+		got := parseTestStructCode(t, srcCode)
+
+		if !cmp.Equal(want, got) {
+			t.Log(srcCode)
+			t.Errorf("%s\n%s\n", tc.in, coloredDiff(want, got))
+		}
+	}
+}
+
+func TestStructPackageNameCollision(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/internal/parser/test/empty"
+		otherempty "github.com/golang-cz/gospeak/internal/parser/test/external/empty"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Test(ctx context.Context) (tst *TestStruct, err error)
+	}
+
+	type TestStruct struct {
+		Empty empty.Struct
+		Other otherempty.Struct
+		More  []otherempty.Struct
+	}
+	`
+
+	webrpcSchema := parseTestAPI(t, srcCode)
+
+	testStruct := webrpcSchema.GetTypeByName("TestStruct")
+	if testStruct == nil {
+		t.Fatal("TestStruct not found")
+	}
+
+	var got []string
+	for _, f := range testStruct.Fields {
+		got = append(got, fmt.Sprintf("%v %v", f.Name, f.Type))
+	}
+	want := []string{
+		"Empty emptyStruct",
+		"Other externalEmptyStruct",
+		"More []externalEmptyStruct",
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("%s", coloredDiff(want, got))
+	}
+
+	emptyStruct := webrpcSchema.GetTypeByName("emptyStruct")
+	if emptyStruct == nil || len(emptyStruct.Meta) != 0 {
+		t.Errorf("expected emptyStruct type without meta, got %#v", emptyStruct)
+	}
+
+	otherStruct := webrpcSchema.GetTypeByName("externalEmptyStruct")
+	if otherStruct == nil {
+		t.Fatal("externalEmptyStruct not found")
+	}
+	wantMeta := []schema.TypeFieldMeta{
+		{"go.type.import": "github.com/golang-cz/gospeak/internal/parser/test/external/empty"},
+	}
+	if !cmp.Equal(wantMeta, otherStruct.Meta) {
+		t.Errorf("%s", coloredDiff(wantMeta, otherStruct.Meta))
+	}
+}
+
+func TestStructPackageNameCollisionOrder(t *testing.T) {
+	t.Parallel()
+
+	// Methods are parsed in the alphabetical order. The names of the colliding
+	// types must not depend on which method references them first.
+	for _, methods := range []string{
+		"A(ctx context.Context) (a *empty.Struct, err error)\n\t\tB(ctx context.Context) (b *otherempty.Struct, err error)",
+		"A(ctx context.Context) (b *otherempty.Struct, err error)\n\t\tB(ctx context.Context) (a *empty.Struct, err error)",
+	} {
+		srcCode := `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/internal/parser/test/empty"
+		otherempty "github.com/golang-cz/gospeak/internal/parser/test/external/empty"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		` + methods + `
+	}
+	`
+
+		webrpcSchema := parseTestAPI(t, srcCode)
+
+		got := map[string]string{}
+		for _, method := range webrpcSchema.Services[0].Methods {
+			got[method.Outputs[0].Name] = method.Outputs[0].Type.String()
+		}
+		want := map[string]string{
+			"a": "emptyStruct",
+			"b": "externalEmptyStruct",
+		}
+		if !cmp.Equal(want, got) {
+			t.Errorf("%s\n%s", methods, coloredDiff(want, got))
+		}
+	}
+}
+
+func TestStructAliasFields(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+
+		"github.com/golang-cz/gospeak/internal/parser/test/empty"
+		"github.com/golang-cz/gospeak/internal/parser/test/external"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Test(ctx context.Context, item *ItemAlias) (tst *TestStruct, err error)
+	}
+
+	type Struct = empty.Struct
+	type ItemAlias = external.Item
+	type ID = int64
+	type IDs = []ID
+	type Inline = struct {
+		Name string
+	}
+
+	type TestStruct struct {
+		ID       ID
+		IDs      IDs
+		Struct   Struct
+		Item     *ItemAlias
+		Items    map[string]ItemAlias
+		Inline   Inline
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	testStruct := schema.GetTypeByName("TestStruct")
+	if testStruct == nil {
+		t.Fatal("TestStruct not found")
+	}
+
+	type field struct {
+		name   string
+		expr   string
+		goType string
+	}
+
+	var got []field
+	for _, f := range testStruct.Fields {
+		got = append(got, field{name: f.Name, expr: f.Type.String(), goType: fmt.Sprint(f.TypeExtra.Meta[1]["go.field.type"])})
+	}
+
+	want := []field{
+		{name: "ID", expr: "int64", goType: "int64"},
+		{name: "IDs", expr: "[]int64", goType: "[]int64"},
+		{name: "Struct", expr: "emptyStruct", goType: "empty.Struct"},
+		{name: "Item", expr: "externalItem", goType: "*external.Item"},
+		{name: "Items", expr: "map<string,externalItem>", goType: "map[string]external.Item"},
+		{name: "Inline", expr: "AnonymousInline", goType: "struct{Name string}"},
+	}
+	if !cmp.Equal(want, got, cmp.AllowUnexported(field{})) {
+		t.Errorf("%s", coloredDiff(want, got, cmp.AllowUnexported(field{})))
+	}
+}
+
+func TestStructSqlNullFields(t *testing.T) {
+	t.Parallel()
+
+	srcCode := `package test
+
+	import (
+		"context"
+		"database/sql"
+	)
+
+	//go:webrpc json -out=/dev/null
+	type TestAPI interface {
+		Test(ctx context.Context) (tst *TestStruct, err error)
+	}
+
+	type TestStruct struct {
+		Name    sql.NullString
+		Count   sql.NullInt64
+		Ratio   *sql.NullFloat64
+		Created sql.NullTime
+		Tags    []sql.NullString
+		Generic sql.Null[uint8]
+	}
+	`
+
+	schema := parseTestAPI(t, srcCode)
+
+	testStruct := schema.GetTypeByName("TestStruct")
+	if testStruct == nil {
+		t.Fatal("TestStruct not found")
+	}
+
+	type field struct {
+		name     string
+		expr     string
+		optional bool
+	}
+
+	var got []field
+	for _, f := range testStruct.Fields {
+		got = append(got, field{name: f.Name, expr: f.Type.String(), optional: f.Optional})
+	}
+
+	want := []field{
+		{name: "Name", expr: "string", optional: true},
+		{name: "Count", expr: "int64", optional: true},
+		{name: "Ratio", expr: "float64", optional: true},
+		{name: "Created", expr: "timestamp", optional: true},
+		{name: "Tags", expr: "[]string"},
+		{name: "Generic", expr: "uint8", optional: true},
+	}
+	if !cmp.Equal(want, got, cmp.AllowUnexported(field{})) {
+		t.Errorf("%s", coloredDiff(want, got, cmp.AllowUnexported(field{})))
+	}
+
+	for _, name := range []string{"sqlNullString", "NullString", "sqlNullInt64"} {
+		if schema.GetTypeByName(name) != nil {
+			t.Errorf("unexpected %v struct in schema", name)
+		}
+	}
+}
+
+func TestStructRecursiveTypes(t *testing.T) {
+	t.Parallel()
+
+	tt := []struct {
+		types string
+		root  string // Type of the TestStruct field, Tree by default.
+		cycle string // Empty if the types are supported.
+	}{
+		{
+			types: "type Tree []Tree",
+			cycle: "Tree -> Tree",
+		},
+		{
+			types: "type Tree map[string]Tree",
+			cycle: "Tree -> Tree",
+		},
+		{
+			types: "type Tree *Tree",
+			cycle: "Tree -> Tree",
+		},
+		{
+			types: "type Tree []Nodes\ntype Nodes map[string]Tree",
+			cycle: "Tree -> Nodes -> Tree",
+		},
+		{
+			types: "type Tree List[int]\ntype List[T any] []List[T]",
+			cycle: "List[int] -> List[int]",
+		},
+		{
+			// Mutually recursive generic structs.
+			types: "type Tree Node[int]\ntype Node[T any] struct{ Children []Branch[T] }\ntype Branch[T any] struct{ Parent *Node[T]; Leaves []Leaves[T] }\ntype Leaves[T any] []Branch[T]",
+		},
+		{
+			// Recursion through a struct, reached by the map first.
+			types: "type Tree map[string]*Node\ntype Node struct{ Children Tree }",
+		},
+		{
+			// Recursion through a struct, reached by the struct first.
+			types: "type Tree map[string]*Node\ntype Node struct{ Children Tree }",
+			root:  "Node",
+		},
+	}
+
+	for _, tc := range tt {
+		root := tc.root
+		if root == "" {
+			root = "Tree"
+		}
+		srcCode := fmt.Sprintf(`package test
+
+		%s
+
+		type TestStruct struct {
+			Tree %s
+		}
+		`, tc.types, root)
+
+		p, err := testParser(srcCode)
+		if err != nil {
+			t.Fatal(fmt.Errorf("error creating test parser: %w", err))
+		}
+
+		err = parseStruct(p, "TestStruct")
+		if tc.cycle == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tc.types, err)
+				continue
+			}
+			for _, typ := range p.Schema.Types {
+				for _, field := range typ.Fields {
+					if field.Type.Type == schema.T_Unknown {
+						t.Errorf("%s: unexpected unknown type of %v.%v %v", tc.types, typ.Name, field.Name, field.Type)
+					}
+					if typ.Name == "Node" && field.Name == "Children" && field.Type.String() != "map<string,Node>" {
+						t.Errorf("%s (root %v): expected Node.Children map<string,Node>, got %v", tc.types, root, field.Type)
+					}
+				}
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), "only structs can refer to themselves: "+tc.cycle+":") {
+			t.Errorf("%s: expected %q cycle error, got %v", tc.types, tc.cycle, err)
+		}
+	}
+}
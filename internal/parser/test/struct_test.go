@@ -84,6 +84,15 @@ func parseTestStructCode(t *testing.T, srcCode string) *schema.Type {
 }
 
 func testParser(srcCode string) (*parser.Parser, error) {
+	return newTestParser(srcCode, false)
+}
+
+// Same as testParser(), but tolerates type-checking errors in the schema package.
+func testParserWithTypeErrors(srcCode string) (*parser.Parser, error) {
+	return newTestParser(srcCode, true)
+}
+
+func newTestParser(srcCode string, allowTypeErrors bool) (*parser.Parser, error) {
 	wd, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("getting working directory: %w", err)
@@ -92,6 +101,8 @@ func testParser(srcCode string) (*parser.Parser, error) {
 	pkg1 := filepath.Join(wd, "proto.go")
 	pkg2 := filepath.Join(wd, "uuid/uuid.go")
 	pkg3 := filepath.Join(wd, "empty/empty.go")
+	pkg4 := filepath.Join(wd, "external/external.go")
+	pkg5 := filepath.Join(wd, "external/empty/empty.go")
 
 	cfg := &packages.Config{
 		Dir:  wd,
@@ -118,22 +129,60 @@ func testParser(srcCode string) (*parser.Parser, error) {
 
 				type Struct struct{}
 			`),
+			pkg4: []byte(`
+				package external
+
+				import (
+					"context"
+
+					"github.com/golang-cz/gospeak/enum"
+				)
+
+				// ReadAPI is declared outside of the schema package.
+				type ReadAPI interface {
+					// GetItem returns a single item.
+					GetItem(ctx context.Context, id int64) (item *Item, err error)
+				}
+
+				type Item struct {
+					ID int64
+				}
+
+				// available
+				// sold
+				type Status enum.Int
+
+				type Kind string
+
+				const (
+					KindCat Kind = "cat"
+					KindDog Kind = "dog"
+				)
+			`),
+			pkg5: []byte(`
+				package empty
+
+				// Struct of the same package name as the empty.Struct.
+				type Struct struct {
+					Name string
+				}
+			`),
 		},
 	}
 
-	pkgs, err := packages.Load(cfg, "file="+pkg1, "file="+pkg2, "file="+pkg3)
+	pkgs, err := packages.Load(cfg, "file="+pkg1, "file="+pkg2, "file="+pkg3, "file="+pkg4, "file="+pkg5)
 	if err != nil {
 		return nil, fmt.Errorf("error loading Go packages: %v\n%s", err, prefixLinesWithLineNumber(srcCode))
 	}
 
 	for _, pkg := range pkgs {
-		if len(pkg.Errors) > 0 {
+		if len(pkg.Errors) > 0 && !(allowTypeErrors && len(pkg.TypeErrors) > 0) {
 			return nil, fmt.Errorf("%v\n%s", spew.Sdump(pkg.Errors), prefixLinesWithLineNumber(srcCode))
 		}
 	}
 
-	if len(pkgs) != 3 {
-		return nil, fmt.Errorf("expected 2 Go packages, got %v\n%s", len(pkgs), spew.Sdump(pkgs))
+	if len(pkgs) != 5 {
+		return nil, fmt.Errorf("expected 5 Go packages, got %v\n%s", len(pkgs), spew.Sdump(pkgs))
 	}
 
 	pkg := pkgs[0]
@@ -167,3 +216,53 @@ func parseStruct(p *parser.Parser, name string) error {
 
 	return nil
 }
+
+func parseTestAPI(t *testing.T, srcCode string) *schema.WebRPCSchema {
+	t.Helper()
+
+	schema, err := testParseAPI(srcCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+func testParseAPI(srcCode string) (*schema.WebRPCSchema, error) {
+	p, err := testParser(srcCode)
+	if err != nil {
+		return nil, fmt.Errorf("error creating test parser: %w", err)
+	}
+
+	if err := p.CollectEnums(); err != nil {
+		return nil, fmt.Errorf("error collecting enums: %w", err)
+	}
+
+	targets, err := gospeak.CollectInterfaces(p.Pkg)
+	if err != nil {
+		return nil, fmt.Errorf("error collecting interfaces: %w", err)
+	}
+	if len(targets) != 1 {
+		return nil, fmt.Errorf("expected 1 //go:webrpc interface, got %v", len(targets))
+	}
+
+	obj := p.Pkg.Types.Scope().Lookup(targets[0].InterfaceName)
+	if obj == nil {
+		return nil, fmt.Errorf("type %s not defined", targets[0].InterfaceName)
+	}
+
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("type %s is %T, expected interface", obj.Name(), obj.Type().Underlying())
+	}
+
+	if err := p.ParseInterfaceMethods(iface, obj.Name()); err != nil {
+		return nil, fmt.Errorf("error parsing interface: %w", err)
+	}
+
+	if len(p.Schema.Services) != 1 {
+		return nil, fmt.Errorf("expected 1 service, got %v", len(p.Schema.Services))
+	}
+
+	return p.Schema, nil
+}
@@ -12,6 +12,10 @@ func (p *Parser) ParseType(typ types.Type) (*schema.VarType, error) {
 }
 
 func (p *Parser) ParseBasic(typ *types.Basic) (*schema.VarType, error) {
+	if typ.Kind() == types.Invalid {
+		return nil, p.refErrorf("invalid type (see type-checking errors above)")
+	}
+
 	var varType schema.VarType
 	if err := schema.ParseVarTypeExpr(p.Schema, typ.Name(), &varType); err != nil {
 		return nil, fmt.Errorf("failed to parse basic type: %v: %w", typ.Name(), err)
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+)
+
+func errChanType(typ *types.Chan) error {
+	return fmt.Errorf("unsupported channel type %v: channels can't be serialized to JSON, use a slice instead (methods can stream values by returning <-chan T)", typ)
+}
+
+// Returns the type that can't be serialized to JSON (channel, func or unsafe.Pointer),
+// nested in the given type, ie. `chan T`, `[]func()` or `map[string]unsafe.Pointer`.
+func findUnsupportedType(typ types.Type) types.Type {
+	return findUnsupportedTypeIn(typ, map[*types.Named]bool{})
+}
+
+// Same as findUnsupportedType(), skips named types already seen, ie. type Tree map[string]Tree.
+func findUnsupportedTypeIn(typ types.Type, seen map[*types.Named]bool) types.Type {
+	switch v := unalias(typ).(type) {
+	case *types.Chan, *types.Signature:
+		return v
+	case *types.Basic:
+		if v.Kind() == types.UnsafePointer {
+			return v
+		}
+	case *types.Pointer:
+		return findUnsupportedTypeIn(v.Elem(), seen)
+	case *types.Slice:
+		return findUnsupportedTypeIn(v.Elem(), seen)
+	case *types.Array:
+		return findUnsupportedTypeIn(v.Elem(), seen)
+	case *types.Map:
+		if unsupported := findUnsupportedTypeIn(v.Key(), seen); unsupported != nil {
+			return unsupported
+		}
+		return findUnsupportedTypeIn(v.Elem(), seen)
+	case *types.Named:
+		if _, ok := v.Underlying().(*types.Struct); ok {
+			return nil // Struct fields are checked one by one.
+		}
+		if seen[v] {
+			return nil
+		}
+		seen[v] = true
+		return findUnsupportedTypeIn(v.Underlying(), seen)
+	}
+	return nil
+}
+
+// Reports struct fields and method arguments of unsupported types along with their source position.
+func (p *Parser) checkUnsupportedType(pos token.Pos, name string, typ types.Type) error {
+	switch unsupported := findUnsupportedType(typ).(type) {
+	case nil:
+		return nil
+	case *types.Chan:
+		return fmt.Errorf("%v: %v: %w", p.Pkg.Fset.Position(pos), name, errChanType(unsupported))
+	default:
+		return fmt.Errorf("%v: %v: unsupported type %v: can't be serialized to JSON", p.Pkg.Fset.Position(pos), name, unsupported)
+	}
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"go/types"
+	"regexp"
+
+	"github.com/webrpc/webrpc/schema"
+)
+
+// Well-known Go types with a fixed JSON representation, keyed by their import path
+// and type name. We don't rely on detecting their encoding.TextMarshaler methods,
+// which don't always match, ie. methods with pointer receivers.
+var wellKnownTypes = map[string]schema.CoreType{
+	// Arbitrary precision numbers, ie. "1.5" or "3/2". The big.Int isn't mapped, since
+	// encoding/json sends it as a number literal, not as a string.
+	"math/big.Float": schema.T_String,
+	"math/big.Rat":   schema.T_String,
+
+	// UUIDs and similar IDs, ie. "6ba7b810-9dad-11d1-80b4-00c04fd430c8".
+	"github.com/google/uuid.UUID":      schema.T_String,
+	"github.com/google/uuid.NullUUID":  schema.T_String,
+	"github.com/gofrs/uuid.UUID":       schema.T_String,
+	"github.com/gofrs/uuid.NullUUID":   schema.T_String,
+	"github.com/satori/go.uuid.UUID":   schema.T_String,
+	"github.com/oklog/ulid.ULID":       schema.T_String,
+	"github.com/rs/xid.ID":             schema.T_String,
+	"github.com/segmentio/ksuid.KSUID": schema.T_String,
+
+	// Network addresses, ie. "192.168.0.1", "10.0.0.0/8" or "[::1]:80".
+	"net.IP":             schema.T_String,
+	"net/netip.Addr":     schema.T_String,
+	"net/netip.AddrPort": schema.T_String,
+	"net/netip.Prefix":   schema.T_String,
+
+	// Decimals.
+	"github.com/shopspring/decimal.Decimal":     schema.T_String,
+	"github.com/shopspring/decimal.NullDecimal": schema.T_String,
+	"github.com/cockroachdb/apd.Decimal":        schema.T_String,
+	"github.com/ericlagergren/decimal.Big":      schema.T_String,
+}
+
+// Well-known generic wrappers of nullable values, keyed by their import path and
+// type name. Struct fields of these types are optional fields of the type argument,
+// ie. Nullable[string] => string. See also sqlNullValueType() and p.NullableTypes.
+var wellKnownNullableTypes = map[string]struct{}{
+	"github.com/golang-cz/gospeak/nullable.Nullable": {},
+	"github.com/guregu/null.Value":                   {},
+}
+
+// Major version suffix of Go module import paths, ie. /v3.
+var majorVersionRegex = regexp.MustCompile(`/v[0-9]+$`)
+
+// Returns the webrpc type of the given Go type, if it's mapped by the user (see
+// p.TypeMappings) or if it's one of the well-known types.
+func (p *Parser) wellKnownType(typ *types.Named) (*schema.VarType, bool) {
+	pkg := typ.Obj().Pkg()
+	if pkg == nil {
+		return nil, false
+	}
+
+	importPath := pkgImportPath(pkg) // app/vendor/github.com/shopspring/decimal => github.com/shopspring/decimal
+	coreType, ok := p.TypeMappings[importPath+"."+typ.Obj().Name()]
+	if !ok {
+		path := majorVersionRegex.ReplaceAllString(importPath, "") // github.com/cockroachdb/apd/v3 => github.com/cockroachdb/apd
+		coreType, ok = wellKnownTypes[path+"."+typ.Obj().Name()]
+	}
+	if !ok && p.URLStrings && importPath == "net/url" && typ.Obj().Name() == "URL" {
+		coreType, ok = schema.T_String, true // See IDEAS.md, url.URL conversion shims.
+	}
+	if !ok {
+		return nil, false
+	}
+
+	return &schema.VarType{
+		Expr: coreType.String(),
+		Type: coreType,
+	}, true
+}
+
+// Returns the value type of nullable types, ie. T for Nullable[T], or nil if the given
+// type is not one of them. Covers database/sql types, well-known generic wrappers and
+// generic wrappers registered by the user (see p.NullableTypes).
+func (p *Parser) nullableValueType(typ *types.Named) types.Type {
+	if valueType := sqlNullValueType(typ); valueType != nil {
+		return valueType
+	}
+
+	pkg := typ.Obj().Pkg()
+	if pkg == nil || typ.TypeArgs().Len() != 1 {
+		return nil
+	}
+
+	key := pkgImportPath(pkg) + "." + typ.Obj().Name()
+	if _, ok := p.NullableTypes[key]; !ok {
+		path := majorVersionRegex.ReplaceAllString(pkgImportPath(pkg), "") // github.com/guregu/null/v5 => github.com/guregu/null
+		if _, ok := wellKnownNullableTypes[path+"."+typ.Obj().Name()]; !ok {
+			return nil
+		}
+	}
+
+	return typ.TypeArgs().At(0)
+}
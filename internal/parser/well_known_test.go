@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/webrpc/webrpc/schema"
+)
+
+func TestWellKnownType(t *testing.T) {
+	tt := []struct {
+		pkgPath  string
+		typeName string
+		out      string
+	}{
+		{pkgPath: "math/big", typeName: "Int"},
+		{pkgPath: "net/netip", typeName: "Prefix", out: "string"},
+		{pkgPath: "net", typeName: "IP", out: "string"},
+		{pkgPath: "net", typeName: "IPNet"},
+		{pkgPath: "net/url", typeName: "URL"}, // Object, unless p.URLStrings is set.
+		{pkgPath: "github.com/shopspring/decimal", typeName: "Decimal", out: "string"},
+		{pkgPath: "github.com/cockroachdb/apd/v3", typeName: "Decimal", out: "string"},
+		{pkgPath: "github.com/cockroachdb/apd/v3", typeName: "Context"},
+		{pkgPath: "github.com/acme/decimal", typeName: "Decimal"},
+		{pkgPath: "github.com/google/uuid", typeName: "UUID", out: "string"},
+		{pkgPath: "github.com/gofrs/uuid/v5", typeName: "UUID", out: "string"},
+		{pkgPath: "github.com/gofrs/uuid/v5", typeName: "Gen"},
+		{pkgPath: "github.com/oklog/ulid/v2", typeName: "ULID", out: "string"},
+		{pkgPath: "github.com/acme/money", typeName: "Money", out: "string"},
+		{pkgPath: "math/big", typeName: "Float", out: "float64"}, // overrides well-known type
+	}
+
+	p := &Parser{
+		TypeMappings: map[string]schema.CoreType{
+			"github.com/acme/money.Money": schema.T_String,
+			"math/big.Float":              schema.T_Float64,
+		},
+	}
+
+	for _, tc := range tt {
+		pkg := types.NewPackage(tc.pkgPath, "pkg")
+		named := types.NewNamed(types.NewTypeName(0, pkg, tc.typeName, nil), types.NewStruct(nil, nil), nil)
+
+		varType, ok := p.wellKnownType(named)
+		if ok != (tc.out != "") {
+			t.Errorf("%v.%v: expected ok=%v", tc.pkgPath, tc.typeName, !ok)
+			continue
+		}
+		if ok && varType.String() != tc.out {
+			t.Errorf("%v.%v: expected %q, got %q", tc.pkgPath, tc.typeName, tc.out, varType.String())
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Package nullable provides the Nullable[T] type for the API models. It doesn't
+// depend on the gospeak generator, so it's safe to import from production code.
+package nullable
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Nullable represents a value that may be null. The parser unwraps it into
+// an optional field of type T, so it's an alternative to pointers, ie.
+//
+//	type Pet struct {
+//		Name nullable.Nullable[string] // optional string
+//	}
+//
+// The zero value is null.
+type Nullable[T any] struct {
+	Value T
+	Valid bool // Valid is true if Value is not null.
+}
+
+// New returns a valid Nullable holding the given value.
+func New[T any](value T) Nullable[T] {
+	return Nullable[T]{Value: value, Valid: true}
+}
+
+// MarshalJSON implements json.Marshaler. Invalid values are sent as null.
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*n = Nullable[T]{}
+		return nil
+	}
+	if err := json.Unmarshal(data, &n.Value); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
@@ -26,8 +26,165 @@ type Target struct {
 	Opts          map[string]interface{}
 }
 
+// Parser options.
+type Options struct {
+	// Omit struct fields of unsupported types (func, chan, unsafe.Pointer)
+	// from the schema with a warning, instead of failing.
+	SkipUnsupportedFields bool
+
+	// Generate targets of all interfaces that parse successfully, even if the
+	// package has errors. Interfaces depending on broken types are reported
+	// and skipped, instead of failing altogether.
+	BestEffort bool
+
+	// Record Go source positions (file.go:line) of all types and methods in the
+	// schema metadata. Off by default, since it changes the schema hash on any
+	// unrelated edit shifting the declarations.
+	Provenance bool
+
+	// Reject types that would be silently sent as `any`, ie. struct fields of
+	// interface types with methods (fmt.Stringer) or types implementing json.Marshaler,
+	// instead of losing their type information in the schema.
+	Strict bool
+
+	// Accept methods without the context.Context argument, ie. Version() (string, error).
+	// They're marked with @go.context:none annotation. Off by default, since the
+	// generated server code needs to support it.
+	AllowNoContext bool
+
+	// Collect enums defined as a named integer or string type with a block of typed
+	// constants, ie. `type Status int` + `const ( StatusApproved Status = iota )`.
+	// Off by default, since it changes the JSON encoding of these types from
+	// numbers to value names.
+	ConstEnums bool
+
+	// Wire format of time.Duration values: integer number of "ns" (default), "us",
+	// "ms" or "s", or "string" (ie. "1h30m"). The format is recorded in the
+	// {"go.duration": format} field meta, so the generators can convert the values.
+	Duration string
+
+	// Wire format of fixed-size byte arrays, ie. [32]byte hashes: "hex" or "base64"
+	// string. Empty by default, which keeps them as lists of numbers, same as
+	// encoding/json. The format is recorded in the {"go.byte_array": format} field meta.
+	ByteArrays string
+
+	// Send url.URL values as strings, ie. "https://example.com/path". Off by default, since
+	// encoding/json sends them as objects with Scheme, Host and Path fields, so the generated
+	// code needs to convert them with String() and url.Parse().
+	URLStrings bool
+
+	// JSON names of struct fields without the json tag name: "camelCase" (ie. userID)
+	// or "snake_case" (ie. user_id), mirroring a custom JSON marshaler of the API.
+	// Empty by default, which keeps the Go field names, same as encoding/json.
+	FieldNames string
+
+	// Struct fields of types declared in internal packages, ie. acme.com/app/internal/model,
+	// can't be imported by the generated code outside of acme.com/app: "error" reports them,
+	// "copy" makes the generators use their own copy of the types. Imported as is by default.
+	InternalTypes string
+
+	// Go workspace file (go.work) used to load the packages, so the schema can reference
+	// types of sibling modules, or "off" to disable the workspace mode. By default, Go
+	// looks for go.work in the package directory and its parents.
+	GoWork string
+
+	// Build tags (ie. "integration") and target GOOS/GOARCH used to load the packages,
+	// so the types guarded by build constraints match the actual service build.
+	BuildTags []string
+	GOOS      string
+	GOARCH    string
+
+	// Load the packages with CGO_ENABLED=0, so packages importing cgo code don't need
+	// a C toolchain. Files with `import "C"` are excluded, as in the pure Go build.
+	NoCgo bool
+
+	// Keep the types not referenced by any of the service methods, ie. unused enums
+	// of the schema package. They're removed from the schema by default.
+	KeepUnusedTypes bool
+
+	// Schema lint rules checked before generating any code, ie. "no-any",
+	// "no-map-results" or "require-comments", see parser.LintRules.
+	Lint []string
+
+	// Custom mappings of Go types to webrpc core types, consulted before any other
+	// parsing, ie. {"github.com/acme/money.Money": "string"}.
+	TypeMappings map[string]string
+
+	// Custom generic wrappers of nullable values, ie. "github.com/acme/opt.Option".
+	// Struct fields of these types are optional fields of the type argument, same
+	// as the built-in nullable.Nullable[T] and sql.Null[T].
+	NullableTypes []string
+}
+
 // Parse Go source file or package folder and return WebRPC schema.
 func Parse(filePath string) ([]*Target, error) {
+	return ParseWithOptions(filePath, Options{})
+}
+
+// ParseWithOptions parses Go source file or package folder and returns WebRPC schema.
+func ParseWithOptions(filePath string, opts Options) ([]*Target, error) {
+	if err := validateOptions(opts); err != nil {
+		return nil, err
+	}
+
+	pkg, err := loadPackage(filePath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect Go interfaces with `//go:webrpc` comments.
+	targets, err := CollectInterfaces(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("collecting Go interfaces: %w", err)
+	}
+
+	return parseTargets(pkg, targets, opts)
+}
+
+func validateOptions(opts Options) error {
+	switch opts.Duration {
+	case "", "ns", "us", "ms", "s", "string":
+	default:
+		return fmt.Errorf("invalid duration format %q, expected ns, us, ms, s or string", opts.Duration)
+	}
+
+	switch opts.ByteArrays {
+	case "", "hex", "base64":
+	default:
+		return fmt.Errorf("invalid byte arrays format %q, expected hex or base64", opts.ByteArrays)
+	}
+
+	switch opts.FieldNames {
+	case "", "camelCase", "snake_case":
+	default:
+		return fmt.Errorf("invalid field names %q, expected camelCase or snake_case", opts.FieldNames)
+	}
+
+	if _, err := parseTypeMappings(opts.TypeMappings); err != nil {
+		return err
+	}
+
+	if _, err := parseNullableTypes(opts.NullableTypes); err != nil {
+		return err
+	}
+
+	switch opts.InternalTypes {
+	case "", "error", "copy":
+	default:
+		return fmt.Errorf("invalid internal types %q, expected error or copy", opts.InternalTypes)
+	}
+
+	for _, rule := range opts.Lint {
+		if _, ok := parser.LintRules[rule]; !ok {
+			return fmt.Errorf("unknown lint rule %q", rule)
+		}
+	}
+
+	return nil
+}
+
+// Loads the Go package of the given source file or package folder.
+func loadPackage(filePath string, opts Options) (*packages.Package, error) {
 	dir, err := filepath.Abs(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get directory from %q: %w", dir, err)
@@ -47,6 +204,32 @@ func Parse(filePath string) ([]*Target, error) {
 		Overlay: map[string][]byte{},
 	}
 
+	var env []string
+	if opts.GoWork != "" {
+		goWork := opts.GoWork
+		if goWork != "off" {
+			if goWork, err = filepath.Abs(goWork); err != nil {
+				return nil, fmt.Errorf("failed to get go.work path from %q: %w", opts.GoWork, err)
+			}
+		}
+		env = append(env, "GOWORK="+goWork)
+	}
+	if opts.GOOS != "" {
+		env = append(env, "GOOS="+opts.GOOS)
+	}
+	if opts.GOARCH != "" {
+		env = append(env, "GOARCH="+opts.GOARCH)
+	}
+	if opts.NoCgo {
+		env = append(env, "CGO_ENABLED=0")
+	}
+	if len(env) > 0 {
+		cfg.Env = append(os.Environ(), env...)
+	}
+	if len(opts.BuildTags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(opts.BuildTags, ",")}
+	}
+
 	packageLine := fmt.Sprintf("package %s", filepath.Base(dir))
 
 	// Make the parser ignore all previously generated Go files to avoid the
@@ -83,50 +266,163 @@ func Parse(filePath string) ([]*Target, error) {
 	}
 	pkg := pkgs[0]
 
-	if len(pkg.Errors) > 0 || len(pkg.TypeErrors) > 0 {
-		return nil, fmt.Errorf("%v errors", len(pkg.Errors)+len(pkg.TypeErrors))
+	if numErrs := len(pkg.Errors) + len(pkg.TypeErrors); numErrs > 0 {
+		if !opts.BestEffort {
+			return nil, fmt.Errorf("%v errors", numErrs)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v errors, generating in best-effort mode\n", numErrs)
 	}
 
-	// Collect Go interfaces with `//go:webrpc` comments.
-	targets, err := CollectInterfaces(pkg)
-	if err != nil {
-		return nil, fmt.Errorf("collecting Go interfaces: %w", err)
-	}
+	return pkg, nil
+}
 
+// Parses the interfaces of the given targets and sets the target schemas.
+func parseTargets(pkg *packages.Package, targets []*Target, opts Options) ([]*Target, error) {
 	cache := map[string]*schema.WebRPCSchema{}
+	failed := map[string]error{}
+	var parsedTargets []*Target
 	for _, target := range targets {
+		if _, ok := failed[target.InterfaceName]; ok {
+			continue
+		}
+
 		if interfaceSchema, ok := cache[target.InterfaceName]; ok {
-			// Hit.
+			// Hit. Interfaces with multiple //go:webrpc targets are parsed only once.
 			target.Schema = interfaceSchema
+			parsedTargets = append(parsedTargets, target)
+			continue
 		}
 
 		// Miss.
-		p := parser.New(pkg)
-		p.Schema.SchemaName = target.InterfaceName
-
-		if err := p.CollectEnums(); err != nil {
-			return nil, fmt.Errorf("collecting enums: %w", err)
+		interfaceSchema, err := parseInterface(pkg, target.InterfaceName, opts)
+		if err != nil {
+			if !opts.BestEffort {
+				return nil, err
+			}
+			fmt.Fprintf(os.Stderr, "skipping %v: %v\n", target.InterfaceName, err)
+			failed[target.InterfaceName] = err
+			continue
 		}
 
-		obj := pkg.Types.Scope().Lookup(target.InterfaceName)
-		if obj == nil {
-			return nil, fmt.Errorf("type interface %v{} not found", target.InterfaceName)
+		target.Schema = interfaceSchema
+		cache[target.InterfaceName] = interfaceSchema
+		parsedTargets = append(parsedTargets, target)
+	}
+
+	if len(parsedTargets) == 0 && len(failed) > 0 {
+		return nil, fmt.Errorf("failed to parse any of %v interfaces", len(failed))
+	}
+
+	return parsedTargets, nil
+}
+
+// Parses the given Go interface and all the types it depends on into a new WebRPC schema.
+func parseInterface(pkg *packages.Package, interfaceName string, opts Options) (*schema.WebRPCSchema, error) {
+	p := parser.New(pkg)
+	p.Schema.SchemaName = interfaceName
+	p.SkipUnsupportedFields = opts.SkipUnsupportedFields
+	p.Provenance = opts.Provenance
+	p.ConstEnums = opts.ConstEnums
+	p.AllowNoContext = opts.AllowNoContext
+	p.Strict = opts.Strict
+	p.Duration = opts.Duration
+	p.ByteArrays = opts.ByteArrays
+	p.URLStrings = opts.URLStrings
+	p.FieldNames = opts.FieldNames
+	p.InternalTypes = opts.InternalTypes
+
+	typeMappings, err := parseTypeMappings(opts.TypeMappings)
+	if err != nil {
+		return nil, err
+	}
+	p.TypeMappings = typeMappings
+
+	nullableTypes, err := parseNullableTypes(opts.NullableTypes)
+	if err != nil {
+		return nil, err
+	}
+	p.NullableTypes = nullableTypes
+
+	if err := p.CollectEnums(); err != nil {
+		return nil, fmt.Errorf("collecting enums: %w", err)
+	}
+
+	if err := p.CollectConsts(); err != nil {
+		return nil, fmt.Errorf("collecting constants: %w", err)
+	}
+
+	obj := pkg.Types.Scope().Lookup(interfaceName)
+	if obj == nil {
+		return nil, fmt.Errorf("type interface %v{} not found", interfaceName)
+	}
+
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("type %v{} is %T", interfaceName, obj.Type().Underlying())
+	}
+
+	if err := p.ParseInterfaceMethods(iface, interfaceName); err != nil {
+		return nil, fmt.Errorf("failed to parse interface %q: %w", interfaceName, err)
+	}
+
+	if !opts.KeepUnusedTypes {
+		p.PruneTypes()
+	}
+
+	for _, warning := range p.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", warning)
+	}
+
+	if err := parser.Lint(p.Schema, opts.Lint); err != nil {
+		return nil, err
+	}
+
+	return p.Schema, nil
+}
+
+// Parses custom type mappings, ie. {"github.com/acme/money.Money": "string"}.
+// Go types can only be mapped to webrpc core types, not lists, maps or structs.
+func parseTypeMappings(mappings map[string]string) (map[string]schema.CoreType, error) {
+	typeMappings := map[string]schema.CoreType{}
+	for goType, webrpcType := range mappings {
+		if !isQualifiedTypeName(goType) {
+			return nil, fmt.Errorf("invalid type mapping %v=%v: expected <import/path>.<Type>, ie. github.com/acme/money.Money", goType, webrpcType)
 		}
 
-		iface, ok := obj.Type().Underlying().(*types.Interface)
+		coreType, ok := schema.CoreTypeFromString[webrpcType]
+		switch coreType {
+		case schema.T_List, schema.T_Map, schema.T_Struct, schema.T_Enum, schema.T_Null:
+			ok = false
+		}
 		if !ok {
-			return nil, fmt.Errorf("type %v{} is %T", target.InterfaceName, obj.Type().Underlying())
+			return nil, fmt.Errorf("invalid type mapping %v=%v: unsupported webrpc type %q", goType, webrpcType, webrpcType)
 		}
 
-		if err := p.ParseInterfaceMethods(iface, target.InterfaceName); err != nil {
-			return nil, fmt.Errorf("failed to parse interface %q: %w", target.InterfaceName, err)
-		}
+		typeMappings[goType] = coreType
+	}
+
+	return typeMappings, nil
+}
 
-		target.Schema = p.Schema
-		cache[target.InterfaceName] = p.Schema
+func parseNullableTypes(goTypes []string) (map[string]struct{}, error) {
+	nullableTypes := map[string]struct{}{}
+	for _, goType := range goTypes {
+		if !isQualifiedTypeName(goType) {
+			return nil, fmt.Errorf("invalid nullable type %v: expected <import/path>.<Type>, ie. github.com/acme/opt.Option", goType)
+		}
+		nullableTypes[goType] = struct{}{}
 	}
 
-	return targets, nil
+	return nullableTypes, nil
+}
+
+// Reports whether the Go type name is qualified by import path, ie. github.com/acme/money.Money.
+func isQualifiedTypeName(goType string) bool {
+	pkgPath, typeName := goType, ""
+	if i := strings.LastIndex(goType, "."); i > 0 {
+		pkgPath, typeName = goType[:i], goType[i+1:]
+	}
+	return pkgPath != "" && typeName != "" && !strings.HasSuffix(pkgPath, "/")
 }
 
 // Find all Go interfaces with the special //go:webrpc comments.
@@ -138,16 +434,24 @@ func CollectInterfaces(pkg *packages.Package) ([]*Target, error) {
 			if typeDeclaration, ok := decl.(*ast.GenDecl); ok && typeDeclaration.Tok == token.TYPE {
 				for _, spec := range typeDeclaration.Specs {
 					if typeSpec, ok := spec.(*ast.TypeSpec); ok {
-						if _, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+						if isInterfaceType(pkg, typeSpec) {
 							doc := typeDeclaration.Doc
 							if doc != nil {
 								for _, comment := range doc.List {
 									if webrpcCmd, hasPrefix := strings.CutPrefix(comment.Text, "//go:webrpc "); hasPrefix {
+										if typeSpec.TypeParams.NumFields() > 0 {
+											return nil, fmt.Errorf("%v: generic interface %v can't be generated, declare its instance instead, ie.:\n\n%v\ntype My%v = %v[MyType]", pkg.Fset.Position(typeSpec.Pos()), typeSpec.Name.Name, comment.Text, typeSpec.Name.Name, typeSpec.Name.Name)
+										}
+
 										target, err := parseWebrpcCommand(webrpcCmd)
 										if err != nil {
 											return nil, fmt.Errorf("failed to parse %s", comment.Text)
 										}
 										target.InterfaceName = typeSpec.Name.Name
+										target.OutFile, err = expandOutFile(target, pkg.Name)
+										if err != nil {
+											return nil, fmt.Errorf("failed to parse %s: %w", comment.Text, err)
+										}
 										targets = append(targets, target)
 									}
 								}
@@ -162,6 +466,30 @@ func CollectInterfaces(pkg *packages.Package) ([]*Target, error) {
 	return targets, nil
 }
 
+// Reports whether the type spec declares an interface. The interface can be
+// declared in place, or defined/aliased from another package, ie.:
+//
+//	type API interface { ... }
+//	type API = other.API
+//	type API other.API
+func isInterfaceType(pkg *packages.Package, typeSpec *ast.TypeSpec) bool {
+	if _, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+		return true
+	}
+
+	if pkg.TypesInfo == nil {
+		return false
+	}
+
+	typ := pkg.TypesInfo.TypeOf(typeSpec.Type)
+	if typ == nil {
+		return false
+	}
+
+	_, ok := typ.Underlying().(*types.Interface)
+	return ok
+}
+
 // Parses webrpc CLI command into a target, ie. webrpc typescript@v0.11.0 -client -out=./videoAuthoringClient.gen.ts.
 func parseWebrpcCommand(cmd string) (*Target, error) {
 	target := &Target{
@@ -194,3 +522,25 @@ func parseWebrpcCommand(cmd string) (*Target, error) {
 
 	return target, nil
 }
+
+// Expands {service}, {package} and {version} placeholders in the -out path, ie.
+// -out=../{package}/{service}.{version}.gen.go, and converts the path to the OS
+// specific format, so the directives can use forward slashes on all platforms.
+func expandOutFile(target *Target, pkgName string) (string, error) {
+	outFile := target.OutFile
+
+	if strings.Contains(outFile, "{version}") {
+		_, version, ok := strings.Cut(target.Generator, "@")
+		if !ok || version == "" {
+			return "", fmt.Errorf("-out uses {version}, but the %q generator is not versioned (ie. golang@v0.11.0)", target.Generator)
+		}
+		outFile = strings.ReplaceAll(outFile, "{version}", version)
+	}
+
+	outFile = strings.NewReplacer(
+		"{service}", target.InterfaceName,
+		"{package}", pkgName,
+	).Replace(outFile)
+
+	return filepath.Clean(filepath.FromSlash(outFile)), nil
+}